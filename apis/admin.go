@@ -0,0 +1,163 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"server-1.1.0/core"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/znet"
+	"time"
+)
+
+// 封禁请求，Duration为封禁秒数，0表示永久封禁
+type banRequest struct {
+	Pid      int32  `json:"pid"`
+	Duration int64  `json:"duration"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+type banResponse struct {
+	Ban   *core.Ban `json:"ban,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+type banListResponse struct {
+	Bans []*core.Ban `json:"bans"`
+}
+
+// 抓包请求，Pid不为0时按玩家当前的链接抓包，否则按ConnID
+type captureRequest struct {
+	ConnID uint32 `json:"connID"`
+	Pid    int32  `json:"pid"`
+}
+
+type captureResponse struct {
+	ConnID uint32 `json:"connID,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// 注册封禁和抓包管理接口，需要在Start之前调用
+// GET {AdminPath}/bans 当前的封禁列表
+// POST {AdminPath}/ban 封禁玩家，在线的玩家立即踢下线
+// POST {AdminPath}/unban 解除封禁
+// POST {AdminPath}/capture/start 按链接ID或玩家ID开始抓包，文件保存在CaptureDir
+// POST {AdminPath}/capture/stop 停止抓包
+func RegisterAdmin(s ziface.IServer) {
+	s.AddAdminHandler("/bans", handleBans)
+	s.AddAdminHandler("/ban", handleBan)
+	s.AddAdminHandler("/unban", handleUnban)
+	s.AddAdminHandler("/capture/start", handleCapture(s, true))
+	s.AddAdminHandler("/capture/stop", handleCapture(s, false))
+}
+
+func handleBans(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&banListResponse{Bans: core.BanMgrObj.List()})
+}
+
+func handleBan(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeBanRequest(w, r)
+	if req == nil {
+		return
+	}
+	var ban *core.Ban
+	if err == nil {
+		ban, err = core.BanMgrObj.Ban(req.Pid, time.Duration(req.Duration)*time.Second, req.Reason, req.Operator)
+	}
+	writeBan(w, &banResponse{Ban: ban}, err)
+}
+
+func handleUnban(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeBanRequest(w, r)
+	if req == nil {
+		return
+	}
+	if err == nil {
+		err = core.BanMgrObj.Unban(req.Pid, req.Reason, req.Operator)
+	}
+	writeBan(w, &banResponse{}, err)
+}
+
+// 解析封禁请求，封禁和解除封禁都需要记录操作人和原因
+// 请求方法错误时直接回复并返回nil
+func decodeBanRequest(w http.ResponseWriter, r *http.Request) (*banRequest, error) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil, nil
+	}
+	req := &banRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return req, err
+	}
+	if req.Operator == "" || req.Reason == "" {
+		return req, errors.New("operator and reason are required")
+	}
+	return req, nil
+}
+
+func writeBan(w http.ResponseWriter, resp *banResponse, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		resp.Error = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// 开始或停止抓包，抓包文件的脱敏和CaptureAll相同
+func handleCapture(s ziface.IServer, start bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		resp := &captureResponse{}
+		conn, err := decodeCaptureConn(s, r)
+		if err == nil {
+			resp.ConnID = conn.GetConnId()
+			if start {
+				if resp.Path = znet.CapturePath(conn.GetConnId()); resp.Path == "" {
+					err = errors.New("CaptureDir is not configured")
+				} else {
+					err = conn.StartCapture(resp.Path)
+				}
+			} else {
+				conn.StopCapture()
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			resp.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// 解析抓包请求，找到要抓包的链接，断线等待重连的玩家没有链接
+func decodeCaptureConn(s ziface.IServer, r *http.Request) (ziface.IConnection, error) {
+	req := &captureRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, err
+	}
+	if req.Pid == 0 {
+		return s.GetConnMgr().Get(req.ConnID)
+	}
+	player := core.WorldMgrObj.GetPlayerByPid(req.Pid)
+	if player == nil {
+		return nil, fmt.Errorf("player %d is not online", req.Pid)
+	}
+	conn := player.GetConn()
+	if conn == nil {
+		return nil, fmt.Errorf("player %d is waiting for reconnect", req.Pid)
+	}
+	return conn, nil
+}
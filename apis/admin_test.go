@@ -0,0 +1,97 @@
+package apis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 调用管理接口，等待管理接口开始监听
+func postAdmin(t *testing.T, path string, req interface{}) (int, *captureResponse) {
+	body, _ := json.Marshal(req)
+	url := fmt.Sprintf("http://127.0.0.1:%d%s%s", utils.Global().AdminPort, utils.Global().AdminPath, path)
+	var httpResp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if httpResp, err = http.Post(url, "application/json", bytes.NewReader(body)); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("post", url, "err:", err)
+	}
+	defer httpResp.Body.Close()
+	resp := &captureResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		t.Fatal("decode admin response err:", err)
+	}
+	return httpResp.StatusCode, resp
+}
+
+// 通过管理接口按玩家ID开始和停止抓包，只记录这期间的消息，密码同样脱敏
+func TestAdminCapture(t *testing.T) {
+	dir := t.TempDir()
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.AdminPort = freePort(t)
+		g.AdminPath = "/admin"
+		g.CaptureDir = dir
+	})
+	c := dialGame(t)
+	c.register("captured", "secret-password")
+	pid := accountPid(t, "captured", "secret-password")
+
+	if code, resp := postAdmin(t, "/capture/start", &captureRequest{ConnID: 9999}); code != http.StatusBadRequest || resp.Error == "" {
+		t.Errorf("capture unknown conn code = %d, resp = %+v, want error", code, resp)
+	}
+	code, resp := postAdmin(t, "/capture/start", &captureRequest{Pid: pid})
+	if code != http.StatusOK || resp.Path == "" {
+		t.Fatalf("capture start code = %d, resp = %+v", code, resp)
+	}
+	c.request(2, &pb.Talk{Content: "captured"})
+	c.request(LoginMsgID, &pb.Login{Account: "captured", Password: "secret-password"})
+	if code, _ := postAdmin(t, "/capture/stop", &captureRequest{ConnID: resp.ConnID}); code != http.StatusOK {
+		t.Fatalf("capture stop code = %d", code)
+	}
+	c.request(2, &pb.Talk{Content: "after stop"})
+
+	if info, err := os.Stat(resp.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("capture file stat = %v, err = %v, want mode 0600", info, err)
+	}
+	data, err := os.ReadFile(resp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret-password")) {
+		t.Error("capture file contains password")
+	}
+	records, err := znet.ReadCapture(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("ReadCapture err:", err)
+	}
+	var talks []string
+	for _, record := range records {
+		if record.ConnID != resp.ConnID {
+			t.Errorf("record connID = %d, want %d", record.ConnID, resp.ConnID)
+		}
+		if record.Dir == znet.CaptureIn && record.MsgID == 2 {
+			talk := &pb.Talk{}
+			if err := proto.Unmarshal(record.Data, talk); err != nil {
+				t.Fatal("unmarshal talk err:", err)
+			}
+			talks = append(talks, talk.Content)
+		}
+	}
+	if len(talks) != 1 || talks[0] != "captured" {
+		t.Errorf("captured talks = %v, want [captured]", talks)
+	}
+}
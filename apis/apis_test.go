@@ -0,0 +1,276 @@
+package apis
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"server-1.1.0/core"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 获取一个空闲的本地端口
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// 启动一个注册了全部游戏路由的服务器，存档保存在临时目录
+// 测试结束时关闭服务器，下线所有等待重连的玩家，再恢复配置
+func startGameServer(t *testing.T, config func(g *utils.GlobalObj)) ziface.IServer {
+	old := utils.Global()
+	g := *old
+	g.Host = "127.0.0.1"
+	g.TcpPort = freePort(t)
+	g.WsPort = 0
+	g.UdpPort = 0
+	g.MetricsPort = 0
+	g.AdminPort = 0
+	g.LocalSavePath = t.TempDir()
+	g.PacketCodec = "flag"
+	g.CompressThreshold = 0
+	g.HandshakeTimeout = 0
+	g.MaxIdleTime = 0
+	g.ConnRateLimit = nil
+	g.MsgRateLimits = nil
+	g.MaxConnPerIP = 0
+	g.ReconnectGrace = 0
+	g.GMAccounts = nil
+	g.CaptureAll = false
+	config(&g)
+	utils.SetGlobal(&g)
+	t.Cleanup(func() { utils.SetGlobal(old) })
+
+	if err := core.LoadAccounts(); err != nil {
+		t.Fatal("load accounts err:", err)
+	}
+	if err := core.LoadBans(); err != nil {
+		t.Fatal("load bans err:", err)
+	}
+	s := znet.NewServer()
+	s.SetOnConnStart(OnConnectionAdd)
+	s.SetOnConnStop(OnConnectionLost)
+	s.Use(znet.Recovery())
+	RegisterRouters(s)
+	RegisterAdmin(s)
+	if err := s.Start(); err != nil {
+		t.Fatal("start server err:", err)
+	}
+	t.Cleanup(func() {
+		s.Stop()
+		for _, player := range core.WorldMgrObj.GetAllPlayers() {
+			core.SessionMgrObj.Remove(player.UserId)
+			player.Offline()
+		}
+	})
+	return s
+}
+
+// 测试用的客户端，使用flag封包格式，请求带有序号
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	dp   ziface.IDataPack
+	seq  uint32
+}
+
+// 连接测试服务器并握手
+func dialGame(t *testing.T) *testClient {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("dial err:", err)
+	}
+	c := &testClient{t: t, conn: conn, dp: znet.NewFlagDataPack()}
+	t.Cleanup(func() { conn.Close() })
+	if resp := c.request(HandshakeMsgID, &pb.Handshake{Version: utils.Global().MinProtocolVersion}); resp.Code != pb.ErrorCode_OK {
+		t.Fatalf("handshake code = %v", resp.Code)
+	}
+	return c
+}
+
+// 发送带序号的请求，返回请求序号
+func (c *testClient) sendRaw(msgID uint32, data []byte) uint32 {
+	c.seq++
+	msg := znet.NewMsgPackage(msgID, data)
+	msg.SetSeq(c.seq)
+	msg.SetFlags(znet.MsgFlagSeq)
+	binaryMsg, err := c.dp.Pack(msg)
+	if err != nil {
+		c.t.Fatal("pack err:", err)
+	}
+	if _, err := c.conn.Write(binaryMsg); err != nil {
+		c.t.Fatal("write err:", err)
+	}
+	return c.seq
+}
+
+func (c *testClient) send(msgID uint32, msg proto.Message) uint32 {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		c.t.Fatal("marshal err:", err)
+	}
+	return c.sendRaw(msgID, data)
+}
+
+// 读取消息直到收到msgID，跳过其他消息
+func (c *testClient) recv(msgID uint32, match func(data []byte) bool) []byte {
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		msg, err := c.dp.Unpack(c.conn)
+		if err != nil {
+			c.t.Fatalf("recv msgID %d err: %v", msgID, err)
+		}
+		if msg.GetMsgId() == msgID && (match == nil || match(msg.GetData())) {
+			return msg.GetData()
+		}
+	}
+}
+
+// 发送请求并等待对应序号的Response
+func (c *testClient) request(msgID uint32, msg proto.Message) *pb.Response {
+	seq := c.send(msgID, msg)
+	return c.response(seq)
+}
+
+func (c *testClient) response(seq uint32) *pb.Response {
+	resp := &pb.Response{}
+	c.recv(ResponseMsgID, func(data []byte) bool {
+		return proto.Unmarshal(data, resp) == nil && resp.Seq == seq
+	})
+	return resp
+}
+
+// 等待服务器发送的消息并解析
+func (c *testClient) recvProto(msgID uint32, msg proto.Message) {
+	if err := proto.Unmarshal(c.recv(msgID, nil), msg); err != nil {
+		c.t.Fatal("unmarshal err:", err)
+	}
+}
+
+// 创建账号并登录，返回登录之后的会话
+func (c *testClient) register(account, password string) *pb.Session {
+	if resp := c.request(CreateAccountMsgID, &pb.CreateAccount{Account: account, Password: password}); resp.Code != pb.ErrorCode_OK {
+		c.t.Fatalf("create account code = %v %s", resp.Code, resp.Msg)
+	}
+	return c.login(&pb.Login{Account: account, Password: password})
+}
+
+// 登录成功之后返回会话
+func (c *testClient) login(msg *pb.Login) *pb.Session {
+	if resp := c.request(LoginMsgID, msg); resp.Code != pb.ErrorCode_OK {
+		c.t.Fatalf("login code = %v %s", resp.Code, resp.Msg)
+	}
+	session := &pb.Session{}
+	c.recvProto(SessionMsgID, session)
+	return session
+}
+
+// 等待服务器关闭链接
+func (c *testClient) waitClosed() {
+	c.conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		if _, err := c.dp.Unpack(c.conn); err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				c.t.Error("connection should be closed by server")
+			}
+			return
+		}
+	}
+}
+
+// 等待条件成立，用于等待其他goroutine中的处理
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Register注册的路由自动解析消息并找到当前玩家，解析失败和没有登录时回复对应的错误码
+func TestRegister(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {})
+	c := dialGame(t)
+
+	if resp := c.request(2, &pb.Talk{Content: "hello"}); resp.Code != pb.ErrorCode_NotLoggedIn {
+		t.Errorf("talk before login code = %v, want NotLoggedIn", resp.Code)
+	}
+	c.register("register", "secret")
+	if resp := c.response(c.sendRaw(2, []byte{0xff})); resp.Code != pb.ErrorCode_BadRequest || resp.ReqMsgId != 2 {
+		t.Errorf("bad talk resp = %v, want BadRequest", resp)
+	}
+	if resp := c.request(2, &pb.Talk{Content: "hello"}); resp.Code != pb.ErrorCode_OK {
+		t.Errorf("talk code = %v %s, want OK", resp.Code, resp.Msg)
+	}
+}
+
+// 抓包文件中没有密码和token，其他字段保持不变
+func TestCaptureRedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.CaptureAll = true
+		g.CaptureDir = dir
+	})
+	c := dialGame(t)
+	session := c.register("capture", "secret-password")
+	c.conn.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("capture files = %v, err = %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := znet.ReadCapture(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("ReadCapture err:", err)
+	}
+	var logins, sessions int
+	for _, record := range records {
+		if bytes.Contains(record.Data, []byte("secret-password")) || bytes.Contains(record.Data, []byte(session.Token)) {
+			t.Errorf("record %s %d contains secret: %q", record.Dir, record.MsgID, record.Data)
+		}
+		switch {
+		case record.Dir == znet.CaptureIn && record.MsgID == LoginMsgID:
+			login := &pb.Login{}
+			if err := proto.Unmarshal(record.Data, login); err != nil || login.Account != "capture" || login.Password != RedactedText {
+				t.Errorf("captured login = %v, err = %v", login, err)
+			}
+			logins++
+		case record.Dir == znet.CaptureOut && record.MsgID == SessionMsgID:
+			captured := &pb.Session{}
+			if err := proto.Unmarshal(record.Data, captured); err != nil || captured.Token != RedactedText || captured.Grace != session.Grace {
+				t.Errorf("captured session = %v, err = %v", captured, err)
+			}
+			sessions++
+		}
+	}
+	if logins != 1 || sessions != 1 {
+		t.Errorf("captured logins = %d, sessions = %d, want 1", logins, sessions)
+	}
+}
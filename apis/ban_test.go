@@ -0,0 +1,72 @@
+package apis
+
+import (
+	"server-1.1.0/core"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/pb/pb"
+	"testing"
+	"time"
+)
+
+// 账号的玩家ID
+func accountPid(t *testing.T, account, password string) int32 {
+	a, err := core.AccountMgrObj.Verify(account, password)
+	if err != nil {
+		t.Fatal("verify err:", err)
+	}
+	return a.Pid
+}
+
+// 封禁在线和断线等待重连的玩家时立即下线，封禁期间不能登录，解除之后可以登录
+func TestBanKicksPlayers(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.ReconnectGrace = 30
+	})
+	online := dialGame(t)
+	online.register("online", "secret")
+	onlinePid := accountPid(t, "online", "secret")
+
+	parked := dialGame(t)
+	parkedSession := parked.register("parked", "secret")
+	parkedPid := accountPid(t, "parked", "secret")
+	parkedPlayer := core.WorldMgrObj.GetPlayerByPid(parkedPid)
+	parked.conn.Close()
+	waitFor(t, "player parked", func() bool { return parkedPlayer.GetConn() == nil })
+
+	if _, err := core.BanMgrObj.Ban(onlinePid, time.Hour, "cheat", "test"); err != nil {
+		t.Fatal("ban online err:", err)
+	}
+	kick := &pb.Kick{}
+	online.recvProto(KickMsgID, kick)
+	if kick.Reason != pb.KickReason_KickBanned || kick.Expire == 0 {
+		t.Errorf("kick = %v, want KickBanned with expire", kick)
+	}
+	online.waitClosed()
+	waitFor(t, "banned player offline", func() bool { return core.WorldMgrObj.GetPlayerByPid(onlinePid) == nil })
+
+	if _, err := core.BanMgrObj.Ban(parkedPid, 0, "cheat", "test"); err != nil {
+		t.Fatal("ban parked err:", err)
+	}
+	waitFor(t, "parked player offline", func() bool { return core.WorldMgrObj.GetPlayerByPid(parkedPid) == nil })
+	c := dialGame(t)
+	if resp := c.request(LoginMsgID, &pb.Login{Token: parkedSession.Token}); resp.Code != pb.ErrorCode_SessionExpired {
+		t.Errorf("resume banned player code = %v, want SessionExpired", resp.Code)
+	}
+
+	c = dialGame(t)
+	if resp := c.request(LoginMsgID, &pb.Login{Account: "online", Password: "secret"}); resp.Code != pb.ErrorCode_Banned {
+		t.Errorf("login while banned code = %v, want Banned", resp.Code)
+	}
+	c.recvProto(KickMsgID, kick)
+	c.waitClosed()
+
+	if err := core.BanMgrObj.Unban(onlinePid, "appeal", "test"); err != nil {
+		t.Fatal("unban err:", err)
+	}
+	c = dialGame(t)
+	c.login(&pb.Login{Account: "online", Password: "secret"})
+	player := core.WorldMgrObj.GetPlayerByPid(onlinePid)
+	if player == nil || !player.GetModPlayer().IsCanEnter() {
+		t.Error("player should enter world after unban")
+	}
+}
@@ -0,0 +1,133 @@
+package apis
+
+import (
+	"server-1.1.0/core"
+	"server-1.1.0/csvs"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/pb/pb"
+	"time"
+)
+
+// 当前客户端建立连接后的hook函数，握手和登录成功之后才创建玩家
+func OnConnectionAdd(conn ziface.IConnection) {
+	waitHandshake(conn)
+}
+
+// 登录成功之后玩家加入世界，player为刚刚加载存档的玩家
+func enterWorld(conn ziface.IConnection, player *core.Player, account string) {
+	syncGM(player, account)
+	name := player.GetModPlayer().Name
+	msg := &pb.Game{
+		Content: name + "请选择功能：1基础信息2背包3角色(八重神子UP池)4地图5圣遗物6角色7武器8存储数据",
+	}
+	player.SendMsg(4, msg)
+
+	//给客户端发送MsgID：1的消息 :同步当前player的id给客户端
+	player.SyncPid()
+	//给客户端发送MsgID：200的消息：同步初始化位置
+	player.BroadCastStartPosition()
+	//将当前新上线玩家添加到worldManager中
+	core.WorldMgrObj.AddPlayer(player)
+	//将该连接绑定一个pid玩家ID的属性
+	conn.Setproperty("pid", player.UserId)
+	//同一个玩家的消息由同一个worker按顺序处理
+	conn.SetDispatchKey(uint32(player.UserId))
+	//同步周边玩家，告知当前玩家上线，广播当前玩家位置
+	player.SynvSurrounding()
+	//下发断线重连使用的token
+	if session, err := core.SessionMgrObj.Create(player.UserId, account); err != nil {
+		player.Log().Warn("create session error", "err", err)
+	} else {
+		sendSession(player, session)
+	}
+	startUnreliable(conn, player)
+
+	player.Log().Info("player is arrived", "connID", conn.GetConnId())
+	checkLostWhileLogin(conn, player)
+}
+
+// 断线重连之后绑定新的链接，只给自己同步数据，周边玩家看到的玩家没有变化
+func resumeWorld(conn ziface.IConnection, player *core.Player, session *core.Session) {
+	conn.Setproperty(accountKey, session.Account)
+	conn.Setproperty("pid", player.UserId)
+	syncGM(player, session.Account)
+	conn.SetDispatchKey(uint32(player.UserId))
+	sendSession(player, session)
+	player.SyncPid()
+	player.BroadCastStartPosition()
+	player.SyncSurroundingToSelf()
+	startUnreliable(conn, player)
+
+	player.Log().Info("player reconnected", "connID", conn.GetConnId())
+	checkLostWhileLogin(conn, player)
+}
+
+// 登录过程中链接已经断开时，OnConnectionLost可能还没有看到玩家ID，这里按断线处理
+func checkLostWhileLogin(conn ziface.IConnection, player *core.Player) {
+	if conn.IsClosed() {
+		playerLost(conn, player.UserId)
+	}
+}
+
+// 根据GMAccounts设置玩家的GM标志
+func syncGM(player *core.Player, account string) {
+	isGM := csvs.LOGIC_FALSE
+	for _, name := range utils.Global().GMAccounts {
+		if name == account {
+			isGM = csvs.LOGIC_TRUE
+			break
+		}
+	}
+	player.GetModPlayer().SetIsGM(isGM)
+}
+
+// 下发会话token，断线之后在ReconnectGrace时间内可以使用token重连
+func sendSession(player *core.Player, session *core.Session) {
+	player.SendMsg(SessionMsgID, &pb.Session{
+		Token: session.Token,
+		Grace: int32(utils.Global().ReconnectGrace),
+	})
+}
+
+// 下发UDP通道的token，之后移动消息可以通过UDP收发
+func startUnreliable(conn ziface.IConnection, player *core.Player) {
+	if utils.Global().UdpPort > 0 {
+		if err := conn.StartUnreliable(); err != nil {
+			player.Log().Warn("start unreliable error", "err", err)
+		}
+	}
+}
+
+// 当前客户端断开连接后的hook函数
+func OnConnectionLost(conn ziface.IConnection) {
+	//获取当前连接的绑定的Pid
+	//没有登录的链接还没有创建玩家
+	pid, err := conn.Getproperty("pid")
+	if err != nil {
+		return
+	}
+	//在玩家的Worker中处理，和登录、踢下线不会同时修改玩家
+	core.RunInPlayerWorker(pid.(int32), func() {
+		playerLost(conn, pid.(int32))
+	})
+}
+
+// 玩家的链接断开，在玩家的Worker中调用
+func playerLost(conn ziface.IConnection, pid int32) {
+	//根据pid获取对应的玩家对象
+	player := core.WorldMgrObj.GetPlayerByPid(pid)
+	if player == nil || player.GetConn() != conn {
+		//玩家已经绑定了新的链接，例如重连或者在其他地方登录
+		return
+	}
+
+	//保留玩家等待重连，周边玩家看到的玩家没有变化，没有开启重连时触发玩家下线业务
+	grace := utils.Global().ReconnectGrace
+	if core.SessionMgrObj.Disconnect(player, conn, time.Duration(grace)*time.Second) {
+		player.Log().Info("player disconnected, wait for reconnect", "connID", conn.GetConnId(), "grace", grace)
+		return
+	}
+
+	player.Log().Info("player left", "connID", conn.GetConnId())
+}
@@ -0,0 +1,212 @@
+package apis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"server-1.1.0/core"
+	"server-1.1.0/pb/pb"
+)
+
+// GM命令，GM账号在世界聊天中发送以/开头的消息时执行，结果只回复给发送者
+type GMCommand struct {
+	Name    string //命令名，不带/
+	Usage   string //参数说明，例如 <item> <n>
+	Help    string //命令说明
+	MinArgs int    //最少参数个数，不够时回复用法
+	//执行命令，返回的内容通过MsgID:4回复给发送者，返回错误时通过Response回复
+	Handle func(player *core.Player, args []string) (string, error)
+}
+
+// 已经注册的GM命令，命令名 -> 命令
+var gmCommands = make(map[string]*GMCommand)
+
+// 注册GM命令，同名的命令会被替换，需要在服务器启动之前调用
+func RegisterGMCommand(cmd *GMCommand) {
+	gmCommands[cmd.Name] = cmd
+}
+
+func init() {
+	RegisterGMCommand(&GMCommand{Name: "help", Usage: "[command]", Help: "查看命令列表或者命令的用法", Handle: gmHelp})
+	RegisterGMCommand(&GMCommand{Name: "give", Usage: "<item> <n>", Help: "获得物品", MinArgs: 2, Handle: gmGive})
+	RegisterGMCommand(&GMCommand{Name: "tp", Usage: "<x> <y> <z>", Help: "传送到指定坐标", MinArgs: 3, Handle: gmTeleport})
+	RegisterGMCommand(&GMCommand{Name: "level", Usage: "<level>", Help: "设置等级，经验清零", MinArgs: 1, Handle: gmLevel})
+	RegisterGMCommand(&GMCommand{Name: "worldlevel", Usage: "<level>", Help: "设置世界等级", MinArgs: 1, Handle: gmWorldLevel})
+	RegisterGMCommand(&GMCommand{Name: "resetevents", Usage: "<map>", Help: "重置地图的全部事件", MinArgs: 1, Handle: gmResetEvents})
+	RegisterGMCommand(&GMCommand{Name: "kick", Usage: "<pid> [reason]", Help: "把玩家踢下线", MinArgs: 1, Handle: gmKick})
+	RegisterGMCommand(&GMCommand{Name: "ban", Usage: "<pid> <duration> <reason>", Help: "封禁玩家，duration例如30m 24h，0表示永久封禁", MinArgs: 3, Handle: gmBan})
+}
+
+// 是否是GM命令，只有GM账号发送的以/开头的消息是命令
+func isGMCommand(player *core.Player, content string) bool {
+	return strings.HasPrefix(content, "/") && player.GetModPlayer().IsGMAccount()
+}
+
+// 解析并执行GM命令
+func handleGMCommand(player *core.Player, content string) error {
+	fields := strings.Fields(strings.TrimPrefix(content, "/"))
+	if len(fields) == 0 {
+		return core.NewGameError(pb.ErrorCode_InvalidParam, "请输入命令，/help查看命令列表")
+	}
+	cmd := gmCommands[strings.ToLower(fields[0])]
+	if cmd == nil {
+		return core.NewGameError(pb.ErrorCode_InvalidParam, "未知命令:%s，/help查看命令列表", fields[0])
+	}
+	args := fields[1:]
+	if len(args) < cmd.MinArgs {
+		return core.NewGameError(pb.ErrorCode_InvalidParam, "用法: %s", cmd.usage())
+	}
+	result, err := cmd.Handle(player, args)
+	if err != nil {
+		return err
+	}
+	player.Log().Info("gm command", "cmd", content)
+	player.SendMsg(4, &pb.Game{Content: result})
+	return nil
+}
+
+func (cmd *GMCommand) usage() string {
+	if cmd.Usage == "" {
+		return "/" + cmd.Name
+	}
+	return "/" + cmd.Name + " " + cmd.Usage
+}
+
+func gmHelp(player *core.Player, args []string) (string, error) {
+	if len(args) > 0 {
+		cmd := gmCommands[strings.ToLower(strings.TrimPrefix(args[0], "/"))]
+		if cmd == nil {
+			return "", core.NewGameError(pb.ErrorCode_InvalidParam, "未知命令:%s", args[0])
+		}
+		return cmd.usage() + " " + cmd.Help, nil
+	}
+	names := make([]string, 0, len(gmCommands))
+	for name := range gmCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		cmd := gmCommands[name]
+		lines = append(lines, cmd.usage()+" "+cmd.Help)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func gmGive(player *core.Player, args []string) (string, error) {
+	itemId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "物品ID错误:%s", args[0])
+	}
+	num, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || num <= 0 {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "物品数量错误:%s", args[1])
+	}
+	if err := player.GetModBag().AddItem(itemId, num); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("获得物品 %d x%d", itemId, num), nil
+}
+
+func gmTeleport(player *core.Player, args []string) (string, error) {
+	var pos [3]float32
+	for i := range pos {
+		v, err := strconv.ParseFloat(args[i], 32)
+		if err != nil {
+			return "", core.NewGameError(pb.ErrorCode_InvalidParam, "坐标错误:%s", args[i])
+		}
+		pos[i] = float32(v)
+	}
+	aoi := core.WorldMgrObj.AoiMgr
+	if !aoi.InArea(pos[0], pos[2]) {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "坐标超出地图范围: x %d-%d z %d-%d", aoi.MinX, aoi.MaxX, aoi.MinY, aoi.MaxY)
+	}
+	player.UpdatePos(pos[0], pos[1], pos[2], player.V)
+	return fmt.Sprintf("传送到 %v %v %v", pos[0], pos[1], pos[2]), nil
+}
+
+func gmLevel(player *core.Player, args []string) (string, error) {
+	level, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "等级错误:%s", args[0])
+	}
+	if err := player.GetModPlayer().SetPlayerLevelGM(level); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("当前等级: %d", level), nil
+}
+
+func gmWorldLevel(player *core.Player, args []string) (string, error) {
+	level, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "世界等级错误:%s", args[0])
+	}
+	if err := player.GetModPlayer().SetWorldLevelGM(level); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("当前世界等级: %d", level), nil
+}
+
+func gmResetEvents(player *core.Player, args []string) (string, error) {
+	mapId, err := strconv.Atoi(args[0])
+	if err != nil {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "地图ID错误:%s", args[0])
+	}
+	if err := player.GetModMap().ResetEventsGM(mapId); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("地图%d的事件已重置", mapId), nil
+}
+
+func gmKick(player *core.Player, args []string) (string, error) {
+	target, err := gmTarget(args[0])
+	if err != nil {
+		return "", err
+	}
+	msg := "被GM踢下线"
+	if len(args) > 1 {
+		msg += "，原因:" + strings.Join(args[1:], " ")
+	}
+	//在目标玩家的Worker中下线，不和目标玩家的消息同时处理
+	core.RunInPlayerWorker(target.UserId, func() {
+		core.SessionMgrObj.KickOffline(target, &pb.Kick{Reason: pb.KickReason_KickGM, Msg: msg})
+	})
+	player.Log().Info("gm kick player", "target", target.UserId, "msg", msg)
+	return fmt.Sprintf("玩家%d已踢下线", target.UserId), nil
+}
+
+func gmBan(player *core.Player, args []string) (string, error) {
+	pid, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil {
+		return "", core.NewGameError(pb.ErrorCode_InvalidParam, "玩家ID错误:%s", args[0])
+	}
+	var duration time.Duration
+	if args[1] != "0" {
+		duration, err = time.ParseDuration(args[1])
+		if err != nil || duration <= 0 {
+			return "", core.NewGameError(pb.ErrorCode_InvalidParam, "封禁时长错误:%s", args[1])
+		}
+	}
+	operator := fmt.Sprintf("gm:%d", player.UserId)
+	ban, err := core.BanMgrObj.Ban(int32(pid), duration, strings.Join(args[2:], " "), operator)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("玩家%d已封禁，到期时间:%s", ban.Pid, ban.ExpireText()), nil
+}
+
+// 在线或者等待重连的玩家
+func gmTarget(arg string) (*core.Player, error) {
+	pid, err := strconv.ParseInt(arg, 10, 32)
+	if err != nil {
+		return nil, core.NewGameError(pb.ErrorCode_InvalidParam, "玩家ID错误:%s", arg)
+	}
+	target := core.WorldMgrObj.GetPlayerByPid(int32(pid))
+	if target == nil {
+		return nil, core.NewGameError(pb.ErrorCode_PlayerNotFound, "玩家不在线:%d", pid)
+	}
+	return target, nil
+}
@@ -0,0 +1,138 @@
+package apis
+
+import (
+	"fmt"
+	"server-1.1.0/core"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/pb/pb"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 执行成功的GM命令，返回通过MsgID:4回复的结果
+func (c *testClient) gm(command string) string {
+	seq := c.send(2, &pb.Talk{Content: command})
+	game := &pb.Game{}
+	c.recvProto(4, game)
+	if resp := c.response(seq); resp.Code != pb.ErrorCode_OK {
+		c.t.Fatalf("%s code = %v %s", command, resp.Code, resp.Msg)
+	}
+	return game.Content
+}
+
+// 执行失败的GM命令，返回Response
+func (c *testClient) gmError(command string) *pb.Response {
+	return c.request(2, &pb.Talk{Content: command})
+}
+
+// 只有GMAccounts中的账号可以执行命令，其他账号以/开头的消息作为聊天广播
+func TestGMOnlyForGMAccounts(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.GMAccounts = []string{"gmaccount"}
+	})
+	gm := dialGame(t)
+	gm.register("gmaccount", "secret")
+	normal := dialGame(t)
+	normal.register("normal", "secret")
+	normalPid := accountPid(t, "normal", "secret")
+
+	seq := normal.send(2, &pb.Talk{Content: "/level 10"})
+	broadcast := &pb.BroadCast{}
+	normal.recv(200, func(data []byte) bool {
+		return proto.Unmarshal(data, broadcast) == nil && broadcast.Tp == 1
+	})
+	if broadcast.Pid != normalPid || broadcast.GetContent() != "/level 10" {
+		t.Errorf("broadcast = %v, want chat /level 10 from %d", broadcast, normalPid)
+	}
+	if resp := normal.response(seq); resp.Code != pb.ErrorCode_OK {
+		t.Errorf("normal /level code = %v", resp.Code)
+	}
+
+	if result := gm.gm("/level 10"); result != "当前等级: 10" {
+		t.Errorf("gm /level result = %s", result)
+	}
+}
+
+// 参数不够时回复用法，参数错误时回复对应的错误，help列出全部命令或者一个命令的用法
+func TestGMCommandArgs(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.GMAccounts = []string{"gmaccount"}
+	})
+	gm := dialGame(t)
+	gm.register("gmaccount", "secret")
+
+	help := gm.gm("/help")
+	for name, cmd := range gmCommands {
+		if !strings.Contains(help, cmd.usage()+" "+cmd.Help) {
+			t.Errorf("help should contain %s: %s", name, help)
+		}
+	}
+	if result := gm.gm("/help /KICK"); result != "/kick <pid> [reason] 把玩家踢下线" {
+		t.Errorf("help kick = %s", result)
+	}
+
+	for _, c := range []struct {
+		command string
+		code    pb.ErrorCode
+		msg     string
+	}{
+		{"/", pb.ErrorCode_InvalidParam, "/help"},
+		{"/nope", pb.ErrorCode_InvalidParam, "未知命令:nope"},
+		{"/help nope", pb.ErrorCode_InvalidParam, "未知命令:nope"},
+		{"/give 1", pb.ErrorCode_InvalidParam, "用法: /give <item> <n>"},
+		{"/give abc 1", pb.ErrorCode_InvalidParam, "物品ID错误:abc"},
+		{"/give 1 0", pb.ErrorCode_InvalidParam, "物品数量错误:0"},
+		{"/tp 1 2", pb.ErrorCode_InvalidParam, "用法: /tp <x> <y> <z>"},
+		{"/tp 1 y 2", pb.ErrorCode_InvalidParam, "坐标错误:y"},
+		{"/level x", pb.ErrorCode_InvalidParam, "等级错误:x"},
+		{"/kick x", pb.ErrorCode_InvalidParam, "玩家ID错误:x"},
+		{"/kick 99999", pb.ErrorCode_PlayerNotFound, "玩家不在线:99999"},
+		{"/ban 1 1h", pb.ErrorCode_InvalidParam, "用法: /ban <pid> <duration> <reason>"},
+		{"/ban 1 -1h cheat", pb.ErrorCode_InvalidParam, "封禁时长错误:-1h"},
+		{"/ban 1 forever cheat", pb.ErrorCode_InvalidParam, "封禁时长错误:forever"},
+	} {
+		resp := gm.gmError(c.command)
+		if resp.Code != c.code || !strings.Contains(resp.Msg, c.msg) {
+			t.Errorf("%s resp = %v %s, want %v %s", c.command, resp.Code, resp.Msg, c.code, c.msg)
+		}
+	}
+}
+
+// GM踢掉在线的玩家时玩家收到Kick并下线，踢掉断线等待重连的玩家之后token失效
+func TestGMKick(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.GMAccounts = []string{"gmaccount"}
+		g.ReconnectGrace = 30
+	})
+	gm := dialGame(t)
+	gm.register("gmaccount", "secret")
+	online := dialGame(t)
+	online.register("online", "secret")
+	onlinePid := accountPid(t, "online", "secret")
+	parked := dialGame(t)
+	parkedSession := parked.register("parked", "secret")
+	parkedPid := accountPid(t, "parked", "secret")
+	parkedPlayer := core.WorldMgrObj.GetPlayerByPid(parkedPid)
+	parked.conn.Close()
+	waitFor(t, "player parked", func() bool { return parkedPlayer.GetConn() == nil })
+
+	if result := gm.gm(fmt.Sprintf("/kick %d spam chat", onlinePid)); result != fmt.Sprintf("玩家%d已踢下线", onlinePid) {
+		t.Errorf("kick result = %s", result)
+	}
+	kick := &pb.Kick{}
+	online.recvProto(KickMsgID, kick)
+	if kick.Reason != pb.KickReason_KickGM || !strings.Contains(kick.Msg, "spam chat") {
+		t.Errorf("kick = %v, want KickGM with reason", kick)
+	}
+	online.waitClosed()
+	waitFor(t, "kicked player offline", func() bool { return core.WorldMgrObj.GetPlayerByPid(onlinePid) == nil })
+
+	gm.gm(fmt.Sprintf("/kick %d", parkedPid))
+	waitFor(t, "parked player offline", func() bool { return core.WorldMgrObj.GetPlayerByPid(parkedPid) == nil })
+	c := dialGame(t)
+	if resp := c.request(LoginMsgID, &pb.Login{Token: parkedSession.Token}); resp.Code != pb.ErrorCode_SessionExpired {
+		t.Errorf("resume kicked player code = %v, want SessionExpired", resp.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package apis
+
+import (
+	"encoding/binary"
+	"server-1.1.0/core"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+	"time"
+)
+
+// 握手的MsgID，数据为pb.Handshake，必须是链接建立之后客户端发送的第一个消息
+const HandshakeMsgID uint32 = 6
+
+// 没有先握手就发送了其他消息或者握手超时，通过RefuseMsgID发送给客户端
+const RefuseHandshakeRequired = znet.RefuseCustom + 1
+
+// 链接属性中保存客户端协议版本号和平台的key，握手成功之后设置
+const (
+	versionKey  = "version"
+	platformKey = "platform"
+)
+
+// 握手，客户端协议版本在配置的范围内时才可以登录，否则拒绝并关闭链接
+func Handshake(request ziface.IRequest, msg *pb.Handshake) {
+	conn := request.GetConnection()
+	if _, err := conn.Getproperty(versionKey); err == nil {
+		Reply(request, core.NewGameError(pb.ErrorCode_BadRequest, "handshake already done"))
+		return
+	}
+	g := utils.Global()
+	if msg.Version < g.MinProtocolVersion || (g.MaxProtocolVersion > 0 && msg.Version > g.MaxProtocolVersion) {
+		zlog.Warn("protocol version mismatch", "connID", conn.GetConnId(), "version", msg.Version,
+			"platform", msg.Platform, "min", g.MinProtocolVersion, "max", g.MaxProtocolVersion)
+		//| reason 4 | min 4 | max 4 |，客户端可以提示更新
+		data := make([]byte, 12)
+		binary.LittleEndian.PutUint32(data, znet.RefuseVersionMismatch)
+		binary.LittleEndian.PutUint32(data[4:], g.MinProtocolVersion)
+		binary.LittleEndian.PutUint32(data[8:], g.MaxProtocolVersion)
+		conn.SendAndStop(znet.RefuseMsgID, data)
+		return
+	}
+	conn.Setproperty(versionKey, msg.Version)
+	conn.Setproperty(platformKey, msg.Platform)
+	Reply(request, nil)
+	zlog.Debug("handshake success", "connID", conn.GetConnId(), "version", msg.Version, "platform", msg.Platform)
+}
+
+// 没有握手的链接发送其他消息时拒绝并关闭链接
+func HandshakeMiddleware(request ziface.IRequest, next func()) {
+	conn := request.GetConnection()
+	if _, err := conn.Getproperty(versionKey); err != nil {
+		zlog.Warn("request before handshake", "connID", conn.GetConnId(), "msgID", request.GetMsgID())
+		refuseHandshake(conn)
+		return
+	}
+	next()
+}
+
+// 链接建立之后在HandshakeTimeout内没有握手时断开链接
+func waitHandshake(conn ziface.IConnection) {
+	timeout := utils.Global().HandshakeTimeout
+	if timeout <= 0 {
+		return
+	}
+	time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		if _, err := conn.Getproperty(versionKey); err == nil || conn.IsClosed() {
+			return
+		}
+		zlog.Warn("handshake timeout", "connID", conn.GetConnId())
+		refuseHandshake(conn)
+	})
+}
+
+func refuseHandshake(conn ziface.IConnection) {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, RefuseHandshakeRequired)
+	conn.SendAndStop(znet.RefuseMsgID, data)
+}
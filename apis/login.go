@@ -0,0 +1,169 @@
+package apis
+
+import (
+	"google.golang.org/protobuf/proto"
+	"server-1.1.0/core"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+)
+
+// 登录的MsgID，数据为pb.Login，握手之后发送
+const LoginMsgID uint32 = 7
+
+// 创建账号的MsgID，数据为pb.CreateAccount
+const CreateAccountMsgID uint32 = 8
+
+// 会话的MsgID，数据为pb.Session，登录成功之后由服务器发送
+const SessionMsgID uint32 = 9
+
+// 踢下线的MsgID，数据为pb.Kick，发送之后服务器关闭链接
+const KickMsgID uint32 = 10
+
+// 链接属性中保存登录账号的key
+const accountKey = "account"
+
+// 链接属性中标记已经开始登录的key，登录在玩家的Worker中处理，处理完之前同一个链接不能再次登录
+const loginKey = "login"
+
+// 登录，校验账号和密码之后创建玩家，玩家ID由账号决定
+// 带有token时重连到同一个玩家，账号已经在线时踢掉旧的链接
+// 创建或者接管玩家在玩家的Worker中处理，和旧的链接的消息、踢下线不会同时修改玩家
+func Login(request ziface.IRequest, msg *pb.Login) {
+	conn := request.GetConnection()
+	if _, err := conn.Getproperty(loginKey); err == nil {
+		Reply(request, core.NewGameError(pb.ErrorCode_BadRequest, "already logged in"))
+		return
+	}
+	if msg.Token != "" {
+		pid, ok := core.SessionMgrObj.Pid(msg.Token)
+		if !ok {
+			zlog.Warn("resume session failed, token not found", "connID", conn.GetConnId())
+			Reply(request, core.NewGameError(pb.ErrorCode_SessionExpired, "会话已过期，请重新登录"))
+			return
+		}
+		conn.Setproperty(loginKey, true)
+		core.RunInPlayerWorker(pid, func() {
+			player, session, err := core.SessionMgrObj.Resume(msg.Token, conn)
+			if err != nil {
+				zlog.Warn("resume session failed", "connID", conn.GetConnId(), "err", err)
+				conn.Removeproperty(loginKey)
+				Reply(request, err)
+				return
+			}
+			Reply(request, nil)
+			resumeWorld(conn, player, session)
+		})
+		return
+	}
+	account, err := core.AccountMgrObj.Verify(msg.Account, msg.Password)
+	if err != nil {
+		zlog.Warn("login failed", "connID", conn.GetConnId(), "account", msg.Account, "err", err)
+		Reply(request, err)
+		return
+	}
+	if ban := core.BanMgrObj.Check(account.Pid); ban != nil {
+		refuseBanned(request, ban)
+		return
+	}
+
+	conn.Setproperty(loginKey, true)
+	core.RunInPlayerWorker(account.Pid, func() {
+		//玩家还在断线等待中或者在其他地方在线时接管玩家
+		if core.WorldMgrObj.GetPlayerByPid(account.Pid) != nil {
+			player, session, err := core.SessionMgrObj.ResumePid(account.Pid, conn)
+			if err != nil {
+				zlog.Warn("login failed", "connID", conn.GetConnId(), "account", msg.Account, "pid", account.Pid, "err", err)
+				conn.Removeproperty(loginKey)
+				Reply(request, err)
+				return
+			}
+			Reply(request, nil)
+			resumeWorld(conn, player, session)
+			return
+		}
+		//加载存档，存档中的封禁状态以封禁管理为准，登录之后封禁的玩家不能进入
+		player := core.NewPlayer(conn, account.Pid)
+		ban := core.BanMgrObj.SyncProhibit(player)
+		if !player.GetModPlayer().IsCanEnter() {
+			conn.Removeproperty(loginKey)
+			refuseBanned(request, ban)
+			return
+		}
+		conn.Setproperty(accountKey, account.Name)
+		Reply(request, nil)
+		enterWorld(conn, player, account.Name)
+	})
+}
+
+// 被封禁的账号回复封禁原因和到期时间，之后发送Kick并关闭链接
+func refuseBanned(request ziface.IRequest, ban *core.Ban) {
+	conn := request.GetConnection()
+	zlog.Warn("login refused, player banned", "connID", conn.GetConnId(), "pid", ban.Pid, "expire", ban.ExpireText())
+	kick := ban.Kick()
+	Reply(request, core.NewGameError(pb.ErrorCode_Banned, "%s", kick.Msg))
+	data, err := proto.Marshal(kick)
+	if err != nil {
+		conn.Stop()
+		return
+	}
+	conn.SendAndStop(KickMsgID, data)
+}
+
+// 创建账号，成功之后客户端使用Login登录
+func CreateAccount(request ziface.IRequest, msg *pb.CreateAccount) {
+	if _, err := core.AccountMgrObj.Register(msg.Account, msg.Password); err != nil {
+		zlog.Warn("create account failed", "connID", request.GetConnection().GetConnId(), "account", msg.Account, "err", err)
+		Reply(request, err)
+		return
+	}
+	Reply(request, nil)
+}
+
+// 抓包时去掉登录、创建账号的密码和token，以及会话中的token，抓包文件不能用来登录
+func RedactCapture(dir string, msgID uint32, data []byte) []byte {
+	//解析失败时不知道敏感字段在哪里，整条数据都不记录
+	var msg proto.Message
+	switch {
+	case dir == znet.CaptureIn && msgID == LoginMsgID:
+		login := &pb.Login{}
+		if err := proto.Unmarshal(data, login); err != nil {
+			return nil
+		}
+		login.Password, login.Token = redacted(login.Password), redacted(login.Token)
+		msg = login
+	case dir == znet.CaptureIn && msgID == CreateAccountMsgID:
+		create := &pb.CreateAccount{}
+		if err := proto.Unmarshal(data, create); err != nil {
+			return nil
+		}
+		create.Password = redacted(create.Password)
+		msg = create
+	case dir == znet.CaptureOut && msgID == SessionMsgID:
+		session := &pb.Session{}
+		if err := proto.Unmarshal(data, session); err != nil {
+			return nil
+		}
+		session.Token = redacted(session.Token)
+		msg = session
+	default:
+		return data
+	}
+	redactedData, err := proto.Marshal(msg)
+	if err != nil {
+		return nil
+	}
+	return redactedData
+}
+
+// 抓包文件中代替密码和token的内容
+const RedactedText = "******"
+
+// 去掉之后的敏感字段，原来为空时保持为空
+func redacted(s string) string {
+	if s == "" {
+		return ""
+	}
+	return RedactedText
+}
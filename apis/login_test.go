@@ -0,0 +1,114 @@
+package apis
+
+import (
+	"server-1.1.0/core"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/pb/pb"
+	"testing"
+)
+
+// 断线之后在ReconnectGrace内使用token重连到同一个玩家，超过之后玩家下线，token失效
+func TestReconnectGrace(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.ReconnectGrace = 1
+	})
+	c := dialGame(t)
+	session := c.register("reconnect", "secret")
+	account, err := core.AccountMgrObj.Verify("reconnect", "secret")
+	if err != nil {
+		t.Fatal("verify err:", err)
+	}
+	pid := account.Pid
+	player := core.WorldMgrObj.GetPlayerByPid(pid)
+	if player == nil {
+		t.Fatal("player should be in world after login")
+	}
+
+	c.conn.Close()
+	waitFor(t, "player disconnected", func() bool { return player.GetConn() == nil })
+	if core.WorldMgrObj.GetPlayerByPid(pid) != player {
+		t.Fatal("player should stay in world within grace")
+	}
+
+	c = dialGame(t)
+	resumed := c.login(&pb.Login{Token: session.Token})
+	if resumed.Token != session.Token || resumed.Grace != 1 {
+		t.Errorf("resumed session = %v, want token %s", resumed, session.Token)
+	}
+	sync := &pb.SyncPid{}
+	c.recvProto(1, sync)
+	if sync.Pid != pid || core.WorldMgrObj.GetPlayerByPid(pid) != player {
+		t.Errorf("resumed pid = %d, want same player %d", sync.Pid, pid)
+	}
+	if resp := c.request(2, &pb.Talk{Content: "back"}); resp.Code != pb.ErrorCode_OK {
+		t.Errorf("talk after resume code = %v %s", resp.Code, resp.Msg)
+	}
+
+	c.conn.Close()
+	waitFor(t, "grace expired", func() bool { return core.WorldMgrObj.GetPlayerByPid(pid) == nil })
+	c = dialGame(t)
+	if resp := c.request(LoginMsgID, &pb.Login{Token: session.Token}); resp.Code != pb.ErrorCode_SessionExpired {
+		t.Errorf("login with expired token code = %v, want SessionExpired", resp.Code)
+	}
+	//过期之后使用账号密码登录，加载存档创建新的玩家
+	c.login(&pb.Login{Account: "reconnect", Password: "secret"})
+	if relogin := core.WorldMgrObj.GetPlayerByPid(pid); relogin == nil || relogin == player {
+		t.Errorf("player after relogin = %p, want new player for pid %d", relogin, pid)
+	}
+}
+
+// 账号不存在和密码错误返回同样的错误，不会创建玩家
+func TestLoginWrongPassword(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {})
+	c := dialGame(t)
+	if resp := c.request(CreateAccountMsgID, &pb.CreateAccount{Account: "wrongpw", Password: "secret"}); resp.Code != pb.ErrorCode_OK {
+		t.Fatalf("create account code = %v %s", resp.Code, resp.Msg)
+	}
+	for _, login := range []*pb.Login{
+		{Account: "wrongpw", Password: "not-secret"},
+		{Account: "nobody", Password: "secret"},
+	} {
+		if resp := c.request(LoginMsgID, login); resp.Code != pb.ErrorCode_LoginFailed {
+			t.Errorf("login %s code = %v, want LoginFailed", login.Account, resp.Code)
+		}
+	}
+	if resp := c.request(2, &pb.Talk{Content: "hello"}); resp.Code != pb.ErrorCode_NotLoggedIn {
+		t.Errorf("talk after failed login code = %v, want NotLoggedIn", resp.Code)
+	}
+	if players := core.WorldMgrObj.GetAllPlayers(); len(players) != 0 {
+		t.Errorf("players in world = %d, want 0", len(players))
+	}
+}
+
+// 同一个账号在其他地方登录时旧的链接收到Kick并被关闭，新的链接接管同一个玩家
+func TestDuplicateLogin(t *testing.T) {
+	startGameServer(t, func(g *utils.GlobalObj) {
+		g.ReconnectGrace = 30
+	})
+	old := dialGame(t)
+	oldSession := old.register("duplicate", "secret")
+	account, err := core.AccountMgrObj.Verify("duplicate", "secret")
+	if err != nil {
+		t.Fatal("verify err:", err)
+	}
+	player := core.WorldMgrObj.GetPlayerByPid(account.Pid)
+
+	c := dialGame(t)
+	session := c.login(&pb.Login{Account: "duplicate", Password: "secret"})
+	if session.Token != oldSession.Token {
+		t.Errorf("token = %s, want the same session %s", session.Token, oldSession.Token)
+	}
+	kick := &pb.Kick{}
+	old.recvProto(KickMsgID, kick)
+	if kick.Reason != pb.KickReason_LoggedInElsewhere {
+		t.Errorf("kick reason = %v, want LoggedInElsewhere", kick.Reason)
+	}
+	old.waitClosed()
+
+	if resp := c.request(2, &pb.Talk{Content: "still here"}); resp.Code != pb.ErrorCode_OK {
+		t.Errorf("talk on new connection code = %v %s", resp.Code, resp.Msg)
+	}
+	if core.WorldMgrObj.GetPlayerByPid(account.Pid) != player || player.GetConn() == nil {
+		t.Error("new connection should keep the same player after old connection closed")
+	}
+}
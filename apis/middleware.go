@@ -0,0 +1,42 @@
+package apis
+
+import (
+	"server-1.1.0/core"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/pb/pb"
+)
+
+// 请求中保存当前玩家的key
+const playerKey = "player"
+
+// 根据链接绑定的pid找到对应的玩家，保存到请求中，没有登录或者找不到玩家时不再处理消息
+func PlayerMiddleware(request ziface.IRequest, next func()) {
+	pid, err := request.GetConnection().Getproperty("pid")
+	if err != nil {
+		zlog.Warn("request before login", "connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID())
+		Reply(request, core.NewGameError(pb.ErrorCode_NotLoggedIn, "not logged in"))
+		return
+	}
+	player := core.WorldMgrObj.GetPlayerByPid(pid.(int32))
+	//玩家已经在其他地方登录，旧的链接正在关闭
+	if player != nil && player.GetConn() != request.GetConnection() {
+		player = nil
+	}
+	if player == nil {
+		zlog.Warn("player not found", "pid", pid, "msgID", request.GetMsgID())
+		Reply(request, core.NewGameError(pb.ErrorCode_PlayerNotFound, "player not found"))
+		return
+	}
+	request.Set(playerKey, player)
+	next()
+}
+
+// 获取PlayerMiddleware保存在请求中的玩家
+func GetPlayer(request ziface.IRequest) *core.Player {
+	value, ok := request.Get(playerKey)
+	if !ok {
+		return nil
+	}
+	return value.(*core.Player)
+}
@@ -0,0 +1,73 @@
+package apis
+
+import (
+	"google.golang.org/protobuf/proto"
+	"server-1.1.0/core"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+)
+
+// 请求结果的MsgID，数据为pb.Response
+const ResponseMsgID uint32 = 5
+
+// 注册一个玩家消息的路由，自动解析protobuf消息并找到当前玩家，没有握手的链接会被拒绝，没有登录的请求回复NotLoggedIn
+// handle返回的错误通过Response回复给客户端
+// 例如 apis.Register(s, 2, func(player *core.Player, msg *pb.Talk) error {...})
+func Register[T any, PT znet.ProtoMessage[T]](s ziface.IServer, msgID uint32, handle func(player *core.Player, msg PT) error) {
+	s.AddRouter(msgID, &znet.ProtoRouter[T, PT]{
+		Handler: func(request ziface.IRequest, msg PT) {
+			player := GetPlayer(request)
+			err := handle(player, msg)
+			if err != nil {
+				player.Log().Warn("handle request failed", "msgID", request.GetMsgID(),
+					"code", core.ErrorCode(err).String(), "err", err)
+			}
+			Reply(request, err)
+		},
+		OnDecodeError: func(request ziface.IRequest, err error) {
+			Reply(request, core.NewGameError(pb.ErrorCode_BadRequest, "%v", err))
+		},
+	}, HandshakeMiddleware, PlayerMiddleware)
+}
+
+// 回复请求的处理结果，客户端携带了请求序号或者处理失败时才回复
+func Reply(request ziface.IRequest, err error) {
+	if err == nil && request.GetSeq() == 0 {
+		return
+	}
+	resp := &pb.Response{
+		Seq:      request.GetSeq(),
+		ReqMsgId: request.GetMsgID(),
+		Code:     core.ErrorCode(err),
+	}
+	if err != nil {
+		resp.Msg = err.Error()
+	}
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		zlog.Error("marshal response error", "msgID", request.GetMsgID(), "err", err)
+		return
+	}
+	if err := request.Reply(ResponseMsgID, data); err != nil {
+		zlog.Warn("reply response error", "connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID(), "err", err)
+	}
+}
+
+// 注册所有游戏业务路由
+func RegisterRouters(s ziface.IServer) {
+	//握手
+	znet.AddProtoRouter(s, HandshakeMsgID, Handshake)
+	//登录和创建账号
+	znet.AddProtoRouter(s, LoginMsgID, Login, HandshakeMiddleware)
+	znet.AddProtoRouter(s, CreateAccountMsgID, CreateAccount, HandshakeMiddleware)
+	//抓包时去掉密码和token
+	s.SetCaptureRedactor(RedactCapture)
+	//世界聊天
+	Register(s, 2, WorldChat)
+	//玩家移动
+	Register(s, 3, Move)
+	//玩家功能选择
+	Register(s, 4, Games)
+}
@@ -0,0 +1,259 @@
+// 抓包回放工具，把抓包文件中客户端发送的消息交给进程内的服务器重新处理
+// 服务器使用真实的MsgHandle、中间件和路由，收发的消息都解析成protobuf文本打印
+//
+//	go run ./cmd/replay -file capture/conn-1-20240101-120000.jsonl
+//	go run ./cmd/replay -file capture/conn-1-20240101-120000.jsonl -dump
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"server-1.1.0/apis"
+	"server-1.1.0/core"
+	"server-1.1.0/csvs"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	file := flag.String("file", "", "抓包文件路径")
+	dump := flag.Bool("dump", false, "只打印抓包文件的内容，不回放")
+	realtime := flag.Bool("realtime", false, "按照抓包时的时间间隔发送")
+	wait := flag.Duration("wait", time.Second, "发送完毕之后等待服务器回复的时间")
+	save := flag.String("save", "", "回放时玩家数据的保存目录，默认使用临时目录")
+	flag.Parse()
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Println("open capture file err:", err)
+		os.Exit(1)
+	}
+	records, err := znet.ReadCapture(f)
+	f.Close()
+	if err != nil {
+		fmt.Println("read capture file err:", err)
+		os.Exit(1)
+	}
+
+	if *dump {
+		for _, record := range records {
+			printMessage(record.Time.Format("15:04:05.000"), record.Dir, record.UDP, record.MsgID, record.Data)
+		}
+		return
+	}
+	if err := replay(records, *realtime, *wait, *save); err != nil {
+		fmt.Println("replay err:", err)
+		os.Exit(1)
+	}
+}
+
+// 启动进程内的服务器，通过本地TCP链接按顺序发送抓包中收到的消息
+func replay(records []*znet.CaptureRecord, realtime bool, wait time.Duration, save string) error {
+	if save == "" {
+		dir, err := os.MkdirTemp("", "zinx-replay")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		save = dir
+	}
+	configure(save)
+
+	s := znet.NewServer()
+	csvs.CheckLoadCsv()
+	if err := prepareAccounts(records); err != nil {
+		return err
+	}
+	s.SetOnConnStart(apis.OnConnectionAdd)
+	s.SetOnConnStop(apis.OnConnectionLost)
+	s.Use(znet.Recovery())
+	apis.RegisterRouters(s)
+	if err := s.Start(); err != nil {
+		return err
+	}
+	defer s.Stop()
+
+	conn, err := dial(net.JoinHostPort(utils.Global().Host, strconv.Itoa(utils.Global().TcpPort)))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	done := make(chan struct{})
+	go printReplies(conn, s.GetPacket(), start, done)
+
+	var first time.Time
+	for _, record := range records {
+		if record.Dir != znet.CaptureIn || znet.IsReservedMsgID(record.MsgID) {
+			continue
+		}
+		if realtime {
+			if first.IsZero() {
+				first = record.Time
+			}
+			time.Sleep(time.Until(start.Add(record.Time.Sub(first))))
+		}
+		msg := znet.NewMsgPackage(record.MsgID, replayData(record))
+		if record.Seq != 0 {
+			msg.SetSeq(record.Seq)
+			msg.SetFlags(znet.MsgFlagSeq)
+		}
+		binaryMsg, err := s.GetPacket().Pack(msg)
+		if err != nil {
+			return err
+		}
+		printMessage(offset(start), record.Dir, record.UDP, record.MsgID, record.Data)
+		if _, err := conn.Write(binaryMsg); err != nil {
+			return err
+		}
+	}
+
+	time.Sleep(wait)
+	conn.Close()
+	<-done
+	return nil
+}
+
+// 抓包中的密码已经去掉，回放时所有账号都使用这个密码
+const replayPassword = "replay"
+
+// 回放使用临时的账号文件，抓包中登录的账号使用replayPassword提前创建
+// 抓包中自己创建的账号不提前创建，回放时和抓包时一样由CreateAccount创建
+// token同样已经去掉，使用token重连的登录会失败
+func prepareAccounts(records []*znet.CaptureRecord) error {
+	if err := core.LoadAccounts(); err != nil {
+		return err
+	}
+	created := make(map[string]bool)
+	for _, record := range records {
+		if record.Dir != znet.CaptureIn {
+			continue
+		}
+		switch record.MsgID {
+		case apis.CreateAccountMsgID:
+			msg := &pb.CreateAccount{}
+			if proto.Unmarshal(record.Data, msg) == nil {
+				created[msg.Account] = true
+			}
+		case apis.LoginMsgID:
+			msg := &pb.Login{}
+			if proto.Unmarshal(record.Data, msg) != nil || msg.Account == "" || created[msg.Account] {
+				continue
+			}
+			//同一个账号可能登录多次，已经创建过的账号跳过
+			if _, err := core.AccountMgrObj.Register(msg.Account, replayPassword); err != nil && core.ErrorCode(err) != pb.ErrorCode_AccountExists {
+				fmt.Println("create account", msg.Account, "err:", err)
+			}
+		}
+	}
+	return nil
+}
+
+// 回放时发送的数据，登录和创建账号的密码替换成replayPassword
+func replayData(record *znet.CaptureRecord) []byte {
+	var msg proto.Message
+	switch record.MsgID {
+	case apis.LoginMsgID:
+		login := &pb.Login{}
+		if proto.Unmarshal(record.Data, login) != nil || login.Account == "" {
+			return record.Data
+		}
+		login.Password = replayPassword
+		msg = login
+	case apis.CreateAccountMsgID:
+		create := &pb.CreateAccount{}
+		if proto.Unmarshal(record.Data, create) != nil {
+			return record.Data
+		}
+		create.Password = replayPassword
+		msg = create
+	default:
+		return record.Data
+	}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return record.Data
+	}
+	return data
+}
+
+// 回放时使用的配置，只监听本地的随机端口，关闭不需要的功能
+func configure(save string) {
+	g := *utils.Global()
+	g.Host = "127.0.0.1"
+	g.TcpPort = freePort()
+	g.WsPort = 0
+	g.UdpPort = 0
+	g.MetricsPort = 0
+	g.AdminPort = 0
+	g.Maintenance = false
+	g.IPAllowList = nil
+	g.IPDenyList = nil
+	g.MaxConnPerIP = 0
+	g.MaxIdleTime = 0
+	g.ConnRateLimit = nil
+	g.MsgRateLimits = nil
+	g.CaptureAll = false
+	//接受所有协议版本，回放较慢时不因为握手超时断开
+	g.MinProtocolVersion = 0
+	g.MaxProtocolVersion = 0
+	g.HandshakeTimeout = 0
+	//不压缩，回复可以直接解析
+	g.CompressThreshold = 0
+	//带标志位的封包格式，保留请求序号
+	g.PacketCodec = "flag"
+	g.LocalSavePath = save
+	utils.SetGlobal(&g)
+	//回放的输出只保留收发的消息
+	zlog.SetLevel(zlog.WarnLevel)
+}
+
+func freePort() int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// 拨号直到服务器开始监听
+func dial(addr string) (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			return conn, nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil, err
+}
+
+// 打印服务器的回复，链接关闭时退出
+func printReplies(conn net.Conn, dp ziface.IDataPack, start time.Time, done chan struct{}) {
+	defer close(done)
+	for {
+		msg, err := dp.Unpack(conn)
+		if err != nil {
+			return
+		}
+		printMessage(offset(start), znet.CaptureOut, false, msg.GetMsgId(), msg.GetData())
+	}
+}
+
+func offset(start time.Time) string {
+	return fmt.Sprintf("+%.3fs", time.Since(start).Seconds())
+}
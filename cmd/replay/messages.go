@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"server-1.1.0/network/znet"
+	"server-1.1.0/pb/pb"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// 客户端发送的消息，和pb/readme.md中的协议一致
+var clientMessages = map[uint32]func() proto.Message{
+	2: func() proto.Message { return &pb.Talk{} },
+	3: func() proto.Message { return &pb.Position{} },
+	4: func() proto.Message { return &pb.Game{} },
+	6: func() proto.Message { return &pb.Handshake{} },
+	7: func() proto.Message { return &pb.Login{} },
+	8: func() proto.Message { return &pb.CreateAccount{} },
+}
+
+// 服务器发送的消息
+var serverMessages = map[uint32]func() proto.Message{
+	1:   func() proto.Message { return &pb.SyncPid{} },
+	4:   func() proto.Message { return &pb.Game{} },
+	5:   func() proto.Message { return &pb.Response{} },
+	9:   func() proto.Message { return &pb.Session{} },
+	10:  func() proto.Message { return &pb.Kick{} },
+	200: func() proto.Message { return &pb.BroadCast{} },
+	201: func() proto.Message { return &pb.SyncPid{} },
+	202: func() proto.Message { return &pb.SyncPlayers{} },
+}
+
+// 框架保留的消息
+var reservedNames = map[uint32]string{
+	znet.PingMsgID:       "Ping",
+	znet.PongMsgID:       "Pong",
+	znet.RateLimitMsgID:  "RateLimit",
+	znet.ServerBusyMsgID: "ServerBusy",
+	znet.RefuseMsgID:     "Refuse",
+	znet.UdpTokenMsgID:   "UdpToken",
+	znet.UdpHelloMsgID:   "UdpHello",
+}
+
+// 打印一条消息，能够解析的数据转换为protobuf文本，否则打印十六进制
+func printMessage(at string, dir string, udp bool, msgID uint32, data []byte) {
+	transport := "tcp"
+	if udp {
+		transport = "udp"
+	}
+	fmt.Printf("%s %-3s %s msgID=%d %s\n", at, dir, transport, msgID, decode(dir, msgID, data))
+}
+
+func decode(dir string, msgID uint32, data []byte) string {
+	if name, ok := reservedNames[msgID]; ok {
+		return fmt.Sprintf("%s [%s]", name, hex.EncodeToString(data))
+	}
+	messages := clientMessages
+	if dir == znet.CaptureOut {
+		messages = serverMessages
+	}
+	newMsg, ok := messages[msgID]
+	if !ok {
+		return fmt.Sprintf("unknown [%s]", hex.EncodeToString(data))
+	}
+	msg := newMsg()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return fmt.Sprintf("decode %s err: %v [%s]", msg.ProtoReflect().Descriptor().Name(), err, hex.EncodeToString(data))
+	}
+	return fmt.Sprintf("%s {%s}", msg.ProtoReflect().Descriptor().Name(), prototext.MarshalOptions{}.Format(msg))
+}
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/pb/pb"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 账号和密码的长度限制
+const (
+	AccountMinLen  = 3
+	AccountMaxLen  = 32
+	PasswordMinLen = 6
+	PasswordMaxLen = 64
+)
+
+// 账号，密码使用bcrypt保存，盐包含在Hash中
+type Account struct {
+	Name    string `json:"name"`
+	Hash    string `json:"hash"`
+	Pid     int32  `json:"pid"`
+	Created int64  `json:"created"`
+}
+
+// 账号管理，账号和下一个玩家ID保存在LocalSavePath/accounts.json，重启之后玩家ID不变
+type AccountManager struct {
+	lock     sync.Mutex
+	path     string
+	NextPid  int32               `json:"nextPid"`
+	Accounts map[string]*Account `json:"accounts"`
+}
+
+// 提供一个对外账号管理模块句柄（全局）
+var AccountMgrObj = &AccountManager{NextPid: 1, Accounts: make(map[string]*Account)}
+
+// 从LocalSavePath加载账号，服务器启动时调用
+// 第一次启动时玩家ID从已有存档目录的最大ID之后开始分配，不会分配到旧的存档
+func LoadAccounts() error {
+	dir := utils.Global().LocalSavePath
+	return AccountMgrObj.load(filepath.Join(dir, "accounts.json"), dir)
+}
+
+func (am *AccountManager) load(path string, saveDir string) error {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+	am.path = path
+	am.NextPid = 1
+	am.Accounts = make(map[string]*Account)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		am.NextPid = maxSavedPid(saveDir) + 1
+		zlog.Info("account file not found, start new", "path", path, "nextPid", am.NextPid)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, am); err != nil {
+		return err
+	}
+	zlog.Info("load accounts", "path", path, "accounts", len(am.Accounts), "nextPid", am.NextPid)
+	return nil
+}
+
+// 存档目录中最大的玩家ID
+func maxSavedPid(saveDir string) int32 {
+	entries, err := os.ReadDir(saveDir)
+	if err != nil {
+		return 0
+	}
+	var max int32
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if pid, err := strconv.ParseInt(entry.Name(), 10, 32); err == nil && int32(pid) > max {
+			max = int32(pid)
+		}
+	}
+	return max
+}
+
+// 创建账号，分配新的玩家ID并立即保存
+func (am *AccountManager) Register(name, password string) (*Account, error) {
+	if len(name) < AccountMinLen || len(name) > AccountMaxLen {
+		return nil, NewGameError(pb.ErrorCode_InvalidParam, "账号长度需要在%d到%d之间", AccountMinLen, AccountMaxLen)
+	}
+	if len(password) < PasswordMinLen || len(password) > PasswordMaxLen {
+		return nil, NewGameError(pb.ErrorCode_InvalidParam, "密码长度需要在%d到%d之间", PasswordMinLen, PasswordMaxLen)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	am.lock.Lock()
+	defer am.lock.Unlock()
+	if _, ok := am.Accounts[name]; ok {
+		return nil, NewGameError(pb.ErrorCode_AccountExists, "账号已存在")
+	}
+	account := &Account{
+		Name:    name,
+		Hash:    string(hash),
+		Pid:     am.NextPid,
+		Created: time.Now().Unix(),
+	}
+	am.Accounts[name] = account
+	am.NextPid++
+	if err := am.save(); err != nil {
+		delete(am.Accounts, name)
+		am.NextPid--
+		return nil, err
+	}
+	zlog.Info("account registered", "account", name, "pid", account.Pid)
+	return account, nil
+}
+
+// 校验账号和密码，账号不存在和密码错误返回同样的错误
+// 账号不存在时同样计算一次bcrypt，不能通过响应时间判断账号是否存在
+func (am *AccountManager) Verify(name, password string) (*Account, error) {
+	am.lock.Lock()
+	account, ok := am.Accounts[name]
+	am.lock.Unlock()
+	if !ok {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return nil, NewGameError(pb.ErrorCode_LoginFailed, "账号或密码错误")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(account.Hash), []byte(password)); err != nil {
+		return nil, NewGameError(pb.ErrorCode_LoginFailed, "账号或密码错误")
+	}
+	return account, nil
+}
+
+// 玩家ID是否属于已有的账号
+func (am *AccountManager) HasPid(pid int32) bool {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+	for _, account := range am.Accounts {
+		if account.Pid == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// 账号不存在时用来比较的hash，和真实账号使用同样的cost，第一次使用时生成
+var (
+	dummyHashOnce  sync.Once
+	dummyHashValue []byte
+)
+
+func dummyHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHashValue, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	return dummyHashValue
+}
+
+// 先写临时文件再替换，保存失败时不会损坏原来的文件，调用时已经持有lock
+func (am *AccountManager) save() error {
+	if am.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(am, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(am.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := am.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, am.path)
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"server-1.1.0/pb/pb"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 创建的账号重新加载之后还在，玩家ID不变，错误的密码不能登录
+func TestAccountPersistence(t *testing.T) {
+	dir := setTestSavePath(t)
+	if err := LoadAccounts(); err != nil {
+		t.Fatal("load accounts err:", err)
+	}
+	account, err := AccountMgrObj.Register("persist", "secret")
+	if err != nil {
+		t.Fatal("register err:", err)
+	}
+	if _, err := AccountMgrObj.Register("persist", "other-secret"); ErrorCode(err) != pb.ErrorCode_AccountExists {
+		t.Errorf("register twice code = %v, want AccountExists", ErrorCode(err))
+	}
+
+	if err := LoadAccounts(); err != nil {
+		t.Fatal("reload accounts err:", err)
+	}
+	loaded, err := AccountMgrObj.Verify("persist", "secret")
+	if err != nil {
+		t.Fatal("verify after reload err:", err)
+	}
+	if loaded.Pid != account.Pid {
+		t.Errorf("pid after reload = %d, want %d", loaded.Pid, account.Pid)
+	}
+	if cost, err := bcrypt.Cost([]byte(loaded.Hash)); err != nil || cost != bcrypt.DefaultCost {
+		t.Errorf("password hash cost = %d, err = %v, want bcrypt default cost", cost, err)
+	}
+	if _, err := AccountMgrObj.Verify("persist", "wrong-password"); ErrorCode(err) != pb.ErrorCode_LoginFailed {
+		t.Errorf("wrong password code = %v, want LoginFailed", ErrorCode(err))
+	}
+	if _, err := AccountMgrObj.Verify("nobody", "secret"); ErrorCode(err) != pb.ErrorCode_LoginFailed {
+		t.Errorf("unknown account code = %v, want LoginFailed", ErrorCode(err))
+	}
+	if info, err := os.Stat(filepath.Join(dir, "accounts.json")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("accounts file stat = %v, err = %v, want mode 0600", info, err)
+	}
+}
+
+// 没有账号文件时，玩家ID从已有存档目录的最大ID之后开始分配
+func TestAccountSkipsSavedPids(t *testing.T) {
+	dir := setTestSavePath(t)
+	for _, name := range []string{"3", "12", "notpid"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := LoadAccounts(); err != nil {
+		t.Fatal("load accounts err:", err)
+	}
+	account, err := AccountMgrObj.Register("newplayer", "secret")
+	if err != nil {
+		t.Fatal("register err:", err)
+	}
+	if account.Pid != 13 {
+		t.Errorf("pid = %d, want 13", account.Pid)
+	}
+}
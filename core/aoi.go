@@ -143,6 +143,11 @@ func (m *AOIManager) GetGidbyPos(x, y float32) int {
 
 }
 
+// 坐标是否在AOI区域内
+func (m *AOIManager) InArea(x, y float32) bool {
+	return int(x) >= m.MinX && int(x) < m.MaxX && int(y) >= m.MinY && int(y) < m.MaxY
+}
+
 // 通过横纵坐标得到周边九宫格内全部的PlayerIDs
 func (m *AOIManager) GetPidsbyPos(x, y float32) (playerIDs []int) {
 	//得到当前玩家的GID格子id
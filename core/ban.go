@@ -0,0 +1,254 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/pb/pb"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 永久封禁时ModPlayer.Prohibit的值
+const ProhibitForever = math.MaxInt
+
+// 封禁记录
+type Ban struct {
+	Pid      int32  `json:"pid"`
+	Expire   int64  `json:"expire"` //到期时间(unix秒)，0表示永久封禁
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+	Created  int64  `json:"created"`
+}
+
+// 是否还在封禁中
+func (b *Ban) Active(now time.Time) bool {
+	return b.Expire == 0 || b.Expire > now.Unix()
+}
+
+// 对应的ModPlayer.Prohibit
+func (b *Ban) Prohibit() int {
+	if b.Expire == 0 {
+		return ProhibitForever
+	}
+	return int(b.Expire)
+}
+
+// 到期时间的描述，用于提示玩家
+func (b *Ban) ExpireText() string {
+	if b.Expire == 0 {
+		return "永久"
+	}
+	return time.Unix(b.Expire, 0).Format("2006-01-02 15:04:05")
+}
+
+// 通知客户端被封禁的消息
+func (b *Ban) Kick() *pb.Kick {
+	return &pb.Kick{
+		Reason: pb.KickReason_KickBanned,
+		Msg:    fmt.Sprintf("账号被封禁，原因:%s，到期时间:%s", b.Reason, b.ExpireText()),
+		Expire: b.Expire,
+	}
+}
+
+// 封禁日志的一条记录，只追加不修改
+type banLogEntry struct {
+	Time     int64  `json:"time"`
+	Action   string `json:"action"` //ban unban
+	Pid      int32  `json:"pid"`
+	Expire   int64  `json:"expire,omitempty"`
+	Reason   string `json:"reason"`
+	Operator string `json:"operator"`
+}
+
+// 封禁管理，当前的封禁保存在LocalSavePath/bans.json，每次修改追加到LocalSavePath/bans.log
+// 在线玩家的封禁同步到ModPlayer.Prohibit
+type BanManager struct {
+	lock    sync.Mutex
+	path    string
+	logPath string
+	Bans    map[int32]*Ban `json:"bans"`
+}
+
+// 提供一个对外封禁管理模块句柄（全局）
+var BanMgrObj = &BanManager{Bans: make(map[int32]*Ban)}
+
+// 从LocalSavePath加载封禁，服务器启动时调用
+func LoadBans() error {
+	dir := utils.Global().LocalSavePath
+	return BanMgrObj.load(filepath.Join(dir, "bans.json"), filepath.Join(dir, "bans.log"))
+}
+
+func (bm *BanManager) load(path, logPath string) error {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	bm.path = path
+	bm.logPath = logPath
+	bm.Bans = make(map[int32]*Ban)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, bm); err != nil {
+		return err
+	}
+	zlog.Info("load bans", "path", path, "bans", len(bm.Bans))
+	return nil
+}
+
+// 封禁玩家，duration为0表示永久封禁，在线的玩家立即踢下线
+func (bm *BanManager) Ban(pid int32, duration time.Duration, reason, operator string) (*Ban, error) {
+	if duration < 0 {
+		return nil, NewGameError(pb.ErrorCode_InvalidParam, "封禁时长不能为负数")
+	}
+	if !AccountMgrObj.HasPid(pid) && WorldMgrObj.GetPlayerByPid(pid) == nil {
+		return nil, NewGameError(pb.ErrorCode_PlayerNotFound, "玩家不存在:%d", pid)
+	}
+	now := time.Now()
+	ban := &Ban{Pid: pid, Reason: reason, Operator: operator, Created: now.Unix()}
+	if duration > 0 {
+		ban.Expire = now.Add(duration).Unix()
+	}
+
+	bm.lock.Lock()
+	old := bm.Bans[pid]
+	bm.Bans[pid] = ban
+	if err := bm.saveLocked(); err != nil {
+		if old != nil {
+			bm.Bans[pid] = old
+		} else {
+			delete(bm.Bans, pid)
+		}
+		bm.lock.Unlock()
+		return nil, err
+	}
+	bm.appendLogLocked(&banLogEntry{Time: now.Unix(), Action: "ban", Pid: pid, Expire: ban.Expire, Reason: reason, Operator: operator})
+	bm.lock.Unlock()
+	zlog.Info("player banned", "pid", pid, "expire", ban.ExpireText(), "reason", reason, "operator", operator)
+
+	//在线或者断线等待重连的玩家在玩家的Worker中立即下线，返回时可能还没有下线
+	RunInPlayerWorker(pid, func() {
+		if player := WorldMgrObj.GetPlayerByPid(pid); player != nil {
+			player.GetModPlayer().SetProhibit(ban.Prohibit())
+			SessionMgrObj.KickOffline(player, ban.Kick())
+		}
+	})
+	return ban, nil
+}
+
+// 解除封禁，玩家下次登录时清除存档中的封禁状态
+func (bm *BanManager) Unban(pid int32, reason, operator string) error {
+	bm.lock.Lock()
+	old := bm.Bans[pid]
+	if old == nil {
+		bm.lock.Unlock()
+		return NewGameError(pb.ErrorCode_InvalidParam, "玩家没有被封禁:%d", pid)
+	}
+	delete(bm.Bans, pid)
+	if err := bm.saveLocked(); err != nil {
+		bm.Bans[pid] = old
+		bm.lock.Unlock()
+		return err
+	}
+	bm.appendLogLocked(&banLogEntry{Time: time.Now().Unix(), Action: "unban", Pid: pid, Reason: reason, Operator: operator})
+	bm.lock.Unlock()
+	zlog.Info("player unbanned", "pid", pid, "reason", reason, "operator", operator)
+
+	//玩家的数据还在内存中时(例如正在下线)同步清除
+	RunInPlayerWorker(pid, func() {
+		if player := WorldMgrObj.GetPlayerByPid(pid); player != nil {
+			bm.SyncProhibit(player)
+		}
+	})
+	return nil
+}
+
+// 把当前的封禁同步到ModPlayer.Prohibit，没有封禁、已经到期或者已经解除时清除，返回当前的封禁
+// 登录时调用，需要在玩家的Worker中调用
+func (bm *BanManager) SyncProhibit(player *Player) *Ban {
+	ban := bm.Check(player.UserId)
+	prohibit := 0
+	if ban != nil {
+		prohibit = ban.Prohibit()
+	}
+	player.GetModPlayer().SetProhibit(prohibit)
+	return ban
+}
+
+// 获取玩家当前的封禁，没有封禁或者已经到期时返回nil
+func (bm *BanManager) Check(pid int32) *Ban {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	ban := bm.Bans[pid]
+	if ban == nil || !ban.Active(time.Now()) {
+		return nil
+	}
+	return ban
+}
+
+// 当前所有还在封禁中的玩家，按照pid排序
+func (bm *BanManager) List() []*Ban {
+	bm.lock.Lock()
+	defer bm.lock.Unlock()
+	now := time.Now()
+	bans := make([]*Ban, 0, len(bm.Bans))
+	for _, ban := range bm.Bans {
+		if ban.Active(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Pid < bans[j].Pid })
+	return bans
+}
+
+// 先写临时文件再替换，封禁文件只有当前用户可以读写，调用时已经持有lock
+func (bm *BanManager) saveLocked() error {
+	if bm.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(bm, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(bm.path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := bm.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, bm.path)
+}
+
+// 追加一条封禁日志，写失败时只打印日志，封禁本身已经保存
+func (bm *BanManager) appendLogLocked(entry *banLogEntry) {
+	if bm.logPath == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(bm.logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		zlog.Error("open ban log error", "path", bm.logPath, "err", err)
+		return
+	}
+	defer f.Close()
+	//之前创建的日志文件权限可能更宽，同样只允许当前用户读写
+	if err := f.Chmod(0600); err != nil {
+		zlog.Warn("chmod ban log error", "path", bm.logPath, "err", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		zlog.Error("write ban log error", "path", bm.logPath, "err", err)
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"server-1.1.0/pb/pb"
+	"testing"
+	"time"
+)
+
+// 限时封禁到期之后不再生效，永久封禁一直生效，Prohibit和封禁一致
+func TestBanExpire(t *testing.T) {
+	now := time.Now()
+	timed := &Ban{Expire: now.Add(time.Hour).Unix()}
+	expired := &Ban{Expire: now.Add(-time.Second).Unix()}
+	forever := &Ban{}
+	if !timed.Active(now) || timed.Active(now.Add(2*time.Hour)) {
+		t.Error("timed ban should be active only before expire")
+	}
+	if expired.Active(now) {
+		t.Error("expired ban should not be active")
+	}
+	if !forever.Active(now.Add(100*365*24*time.Hour)) || forever.Prohibit() != ProhibitForever {
+		t.Errorf("forever ban should always be active, prohibit = %d", forever.Prohibit())
+	}
+
+	setTestSavePath(t)
+	if err := LoadBans(); err != nil {
+		t.Fatal("load bans err:", err)
+	}
+	p := NewPlayer(nil, 7)
+	for _, c := range []struct {
+		name     string
+		ban      *Ban
+		canEnter bool
+	}{
+		{"timed", timed, false},
+		{"expired", expired, true},
+		{"forever", forever, false},
+		{"none", nil, true},
+	} {
+		delete(BanMgrObj.Bans, 7)
+		if c.ban != nil {
+			c.ban.Pid = 7
+			BanMgrObj.Bans[7] = c.ban
+		}
+		p.GetModPlayer().SetProhibit(ProhibitForever)
+		BanMgrObj.SyncProhibit(p)
+		if got := p.GetModPlayer().IsCanEnter(); got != c.canEnter {
+			t.Errorf("%s ban: IsCanEnter = %v, want %v", c.name, got, c.canEnter)
+		}
+	}
+}
+
+// 封禁和解除封禁保存到bans.json，重新加载之后不变，每次修改追加一条日志
+func TestBanUnbanAndLog(t *testing.T) {
+	dir := setTestSavePath(t)
+	if err := LoadAccounts(); err != nil {
+		t.Fatal("load accounts err:", err)
+	}
+	if err := LoadBans(); err != nil {
+		t.Fatal("load bans err:", err)
+	}
+	account, err := AccountMgrObj.Register("banned", "secret")
+	if err != nil {
+		t.Fatal("register err:", err)
+	}
+	pid := account.Pid
+
+	if _, err := BanMgrObj.Ban(pid+100, 0, "nobody", "test"); ErrorCode(err) != pb.ErrorCode_PlayerNotFound {
+		t.Errorf("ban unknown pid code = %v, want PlayerNotFound", ErrorCode(err))
+	}
+	if _, err := BanMgrObj.Ban(pid, -time.Hour, "negative", "test"); ErrorCode(err) != pb.ErrorCode_InvalidParam {
+		t.Errorf("ban negative duration code = %v, want InvalidParam", ErrorCode(err))
+	}
+	ban, err := BanMgrObj.Ban(pid, time.Hour, "cheat", "test")
+	if err != nil {
+		t.Fatal("ban err:", err)
+	}
+	if ban.Expire <= time.Now().Unix() {
+		t.Errorf("expire = %d, want in an hour", ban.Expire)
+	}
+
+	if err := LoadBans(); err != nil {
+		t.Fatal("reload bans err:", err)
+	}
+	if loaded := BanMgrObj.Check(pid); loaded == nil || loaded.Reason != "cheat" || loaded.Expire != ban.Expire {
+		t.Errorf("ban after reload = %+v, want %+v", loaded, ban)
+	}
+	if bans := BanMgrObj.List(); len(bans) != 1 || bans[0].Pid != pid {
+		t.Errorf("list = %+v, want ban of %d", bans, pid)
+	}
+
+	if err := BanMgrObj.Unban(pid, "appeal", "test"); err != nil {
+		t.Fatal("unban err:", err)
+	}
+	if BanMgrObj.Check(pid) != nil {
+		t.Error("player should not be banned after unban")
+	}
+	if err := BanMgrObj.Unban(pid, "again", "test"); ErrorCode(err) != pb.ErrorCode_InvalidParam {
+		t.Errorf("unban twice code = %v, want InvalidParam", ErrorCode(err))
+	}
+	if err := LoadBans(); err != nil {
+		t.Fatal("reload bans err:", err)
+	}
+	if BanMgrObj.Check(pid) != nil {
+		t.Error("unban should be saved")
+	}
+
+	for _, name := range []string{"bans.json", "bans.log"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("%s stat = %v, err = %v, want mode 0600", name, info, err)
+		}
+	}
+	f, err := os.Open(filepath.Join(dir, "bans.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var actions []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := &banLogEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			t.Fatal("ban log line err:", err)
+		}
+		if entry.Pid != pid || entry.Operator != "test" {
+			t.Errorf("ban log entry = %+v", entry)
+		}
+		actions = append(actions, entry.Action+":"+entry.Reason)
+	}
+	if len(actions) != 2 || actions[0] != "ban:cheat" || actions[1] != "unban:appeal" {
+		t.Errorf("ban log = %v, want [ban:cheat unban:appeal]", actions)
+	}
+}
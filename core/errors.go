@@ -0,0 +1,34 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"server-1.1.0/pb/pb"
+)
+
+// 游戏业务错误，带有返回给客户端的错误码
+type GameError struct {
+	Code pb.ErrorCode
+	Msg  string
+}
+
+func (e *GameError) Error() string {
+	return e.Msg
+}
+
+// 创建一个业务错误
+func NewGameError(code pb.ErrorCode, format string, args ...interface{}) *GameError {
+	return &GameError{Code: code, Msg: fmt.Sprintf(format, args...)}
+}
+
+// 获取错误对应的错误码，nil为OK，不是业务错误的为Unknown
+func ErrorCode(err error) pb.ErrorCode {
+	if err == nil {
+		return pb.ErrorCode_OK
+	}
+	var gameErr *GameError
+	if errors.As(err, &gameErr) {
+		return gameErr.Code
+	}
+	return pb.ErrorCode_Unknown
+}
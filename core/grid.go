@@ -63,6 +63,13 @@ func (g *Grid) GetPlayerIDs() (playerIDs []int) {
 
 }
 
+// 得到当前格子中的玩家数量
+func (g *Grid) PlayerCount() int {
+	g.pIDLock.RLock()
+	defer g.pIDLock.RUnlock()
+	return len(g.playerIDs)
+}
+
 // 调试使用---打印信息方法打印出格子基本信息
 func (g *Grid) String() string {
 	return fmt.Sprintf("Grid id: %d,minX:%d,maxX:%d,minY:%d,maxY:%d,playerIDs:%v",
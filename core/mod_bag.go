@@ -2,10 +2,10 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
+	"server-1.1.0/pb/pb"
 )
 
 type ItemInfo struct {
@@ -20,11 +20,10 @@ type ModBag struct {
 	path   string
 }
 
-func (self *ModBag) AddItem(itemId int, num int64) {
+func (self *ModBag) AddItem(itemId int, num int64) error {
 	itemConfig := csvs.GetItemConfig(itemId)
 	if itemConfig == nil {
-		fmt.Println(itemId, "物品不存在")
-		return
+		return NewGameError(pb.ErrorCode_ItemNotFound, "%d物品不存在", itemId)
 	}
 	switch itemConfig.SortType {
 	//case csvs.ITEMTYPE_NORMAL:
@@ -46,6 +45,7 @@ func (self *ModBag) AddItem(itemId int, num int64) {
 	default: //同普通
 		self.AddItemToBag(itemId, num)
 	}
+	return nil
 }
 
 func (self *ModBag) AddItemToBag(itemId int, num int64) {
@@ -57,16 +57,15 @@ func (self *ModBag) AddItemToBag(itemId int, num int64) {
 	}
 	config := csvs.GetItemConfig(itemId)
 	if config != nil {
-		fmt.Println("获得物品", config.ItemName, "----数量：", num, "----当前数量：", self.BagInfo[itemId].ItemNum)
+		self.player.Log().Infof("获得物品 %v ----数量： %v ----当前数量： %v", config.ItemName, num, self.BagInfo[itemId].ItemNum)
 	}
 
 }
 
-func (self *ModBag) RemoveItem(itemId int, num int64) {
+func (self *ModBag) RemoveItem(itemId int, num int64) error {
 	itemConfig := csvs.GetItemConfig(itemId)
 	if itemConfig == nil {
-		fmt.Println(itemId, "物品不存在")
-		return
+		return NewGameError(pb.ErrorCode_ItemNotFound, "%d物品不存在", itemId)
 	}
 
 	switch itemConfig.SortType {
@@ -75,6 +74,7 @@ func (self *ModBag) RemoveItem(itemId int, num int64) {
 	default: //同普通
 		//self.AddItemToBag(itemId, 1)
 	}
+	return nil
 }
 
 func (self *ModBag) RemoveItemToBagGM(itemId int, num int64) {
@@ -86,38 +86,25 @@ func (self *ModBag) RemoveItemToBagGM(itemId int, num int64) {
 	}
 	config := csvs.GetItemConfig(itemId)
 	if config != nil {
-		fmt.Println("扣除物品", config.ItemName, "----数量：", num, "----当前数量：", self.BagInfo[itemId].ItemNum)
+		self.player.Log().Infof("扣除物品 %v ----数量： %v ----当前数量： %v", config.ItemName, num, self.BagInfo[itemId].ItemNum)
 	}
 }
 
-func (self *ModBag) RemoveItemToBag(itemId int, num int64) {
+func (self *ModBag) RemoveItemToBag(itemId int, num int64) error {
 	itemConfig := csvs.GetItemConfig(itemId)
+	if itemConfig == nil {
+		return NewGameError(pb.ErrorCode_ItemNotFound, "%d物品不存在", itemId)
+	}
 	switch itemConfig.SortType {
 	//case csvs.ITEMTYPE_NORMAL:
 	//	self.AddItemToBag(itemId, num)
-	case csvs.ITEMTYPE_ROLE:
-		fmt.Println("此物品无法扣除")
-		return
-	case csvs.ITEMTYPE_ICON:
-		fmt.Println("此物品无法扣除")
-		return
-	case csvs.ITEMTYPE_CARD:
-		fmt.Println("此物品无法扣除")
-		return
+	case csvs.ITEMTYPE_ROLE, csvs.ITEMTYPE_ICON, csvs.ITEMTYPE_CARD:
+		return NewGameError(pb.ErrorCode_ItemCannotUse, "%s此物品无法扣除", itemConfig.ItemName)
 	default: //同普通
 	}
 
 	if !self.HasEnoughItem(itemId, num) {
-		config := csvs.GetItemConfig(itemId)
-		if config != nil {
-			nowNum := int64(0)
-			_, ok := self.BagInfo[itemId]
-			if ok {
-				nowNum = self.BagInfo[itemId].ItemNum
-			}
-			fmt.Println(config.ItemName, "数量不足", "----当前数量：", nowNum)
-		}
-		return
+		return self.notEnoughError(itemId)
 	}
 
 	_, ok := self.BagInfo[itemId]
@@ -126,7 +113,18 @@ func (self *ModBag) RemoveItemToBag(itemId int, num int64) {
 	} else {
 		self.BagInfo[itemId] = &ItemInfo{ItemId: itemId, ItemNum: 0 - num}
 	}
-	fmt.Println("扣除物品", itemConfig.ItemName, "----数量：", num, "----当前数量：", self.BagInfo[itemId].ItemNum)
+	self.player.Log().Infof("扣除物品 %v ----数量： %v ----当前数量： %v", itemConfig.ItemName, num, self.BagInfo[itemId].ItemNum)
+	return nil
+}
+
+// 物品数量不足的错误
+func (self *ModBag) notEnoughError(itemId int) error {
+	nowNum := int64(0)
+	_, ok := self.BagInfo[itemId]
+	if ok {
+		nowNum = self.BagInfo[itemId].ItemNum
+	}
+	return NewGameError(pb.ErrorCode_ItemNotEnough, "%s数量不足----当前数量：%d", csvs.GetItemName(itemId), nowNum)
 }
 
 func (self *ModBag) HasEnoughItem(itemId int, num int64) bool {
@@ -142,45 +140,36 @@ func (self *ModBag) HasEnoughItem(itemId int, num int64) bool {
 	return true
 }
 
-func (self *ModBag) UseItem(itemId int, num int64) {
+func (self *ModBag) UseItem(itemId int, num int64) error {
 	itemConfig := csvs.GetItemConfig(itemId)
 	if itemConfig == nil {
-		fmt.Println(itemId, "物品不存在")
-		return
+		return NewGameError(pb.ErrorCode_ItemNotFound, "%d物品不存在", itemId)
 	}
 
 	if !self.HasEnoughItem(itemId, num) {
-		config := csvs.GetItemConfig(itemId)
-		if config != nil {
-			nowNum := int64(0)
-			_, ok := self.BagInfo[itemId]
-			if ok {
-				nowNum = self.BagInfo[itemId].ItemNum
-			}
-			fmt.Println(config.ItemName, "数量不足", "----当前数量：", nowNum)
-		}
-		return
+		return self.notEnoughError(itemId)
 	}
 
 	switch itemConfig.SortType {
 	case csvs.ITEMTYPE_COOKBOOK:
-		self.UseCookBook(itemId, num)
+		return self.UseCookBook(itemId, num)
 	case csvs.ITEMTYPE_FOOD:
 		//给英雄加属性
 	default: //同普通
-		fmt.Println(itemId, "此物品无法使用")
-		return
+		return NewGameError(pb.ErrorCode_ItemCannotUse, "%d此物品无法使用", itemId)
 	}
+	return nil
 }
 
-func (self *ModBag) UseCookBook(itemId int, num int64) {
+func (self *ModBag) UseCookBook(itemId int, num int64) error {
 	cookBookConfig := csvs.GetCookBookConfig(itemId)
 	if cookBookConfig == nil {
-		fmt.Println(itemId, "物品不存在")
-		return
+		return NewGameError(pb.ErrorCode_ItemNotFound, "%d物品不存在", itemId)
 	}
-	self.RemoveItem(itemId, num)
-	self.AddItem(cookBookConfig.Reward, num)
+	if err := self.RemoveItem(itemId, num); err != nil {
+		return err
+	}
+	return self.AddItem(cookBookConfig.Reward, num)
 }
 
 func (self *ModBag) GetItemNum(itemId int) int64 {
@@ -213,7 +202,8 @@ func (self *ModBag) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
+		self.InitData()
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -229,5 +219,7 @@ func (self *ModBag) LoadData(player *Player) {
 }
 
 func (self *ModBag) InitData() {
-
+	if self.BagInfo == nil {
+		self.BagInfo = make(map[int]*ItemInfo)
+	}
 }
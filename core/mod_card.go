@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -27,21 +26,21 @@ func (self *ModCard) IsHasCard(cardId int) bool {
 func (self *ModCard) AddItem(itemId int, friendliness int) {
 	_, ok := self.CardInfo[itemId]
 	if ok {
-		fmt.Println("已存在名片：", itemId)
+		self.player.Log().Warnf("已存在名片： %v", itemId)
 		return
 	}
 	config := csvs.GetCardConfig(itemId)
 	if config == nil {
-		fmt.Println("非法名片：", itemId)
+		self.player.Log().Warnf("非法名片： %v", itemId)
 		return
 	}
 	if friendliness < config.Friendliness {
-		fmt.Println("好感度不足：", itemId)
+		self.player.Log().Warnf("好感度不足： %v", itemId)
 		return
 	}
 
 	self.CardInfo[itemId] = &Card{CardId: itemId}
-	fmt.Println("获得名片：", itemId)
+	self.player.Log().Infof("获得名片： %v", itemId)
 }
 
 func (self *ModCard) CheckGetCard(roleId int, friendliness int) {
@@ -70,7 +69,7 @@ func (self *ModCard) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -22,16 +21,16 @@ type ModCook struct {
 func (self *ModCook) AddItem(itemId int) {
 	_, ok := self.CookInfo[itemId]
 	if ok {
-		fmt.Println("已习得：", csvs.GetItemName(itemId))
+		self.player.Log().Warnf("已习得： %v", csvs.GetItemName(itemId))
 		return
 	}
 	config := csvs.GetCookConfig(itemId)
 	if config == nil {
-		fmt.Println("没有这个烹饪技能：", csvs.GetItemName(itemId))
+		self.player.Log().Warnf("没有这个烹饪技能： %v", csvs.GetItemName(itemId))
 		return
 	}
 	self.CookInfo[itemId] = &Cook{CookId: itemId}
-	fmt.Println("学会烹饪：", itemId)
+	self.player.Log().Infof("学会烹饪： %v", itemId)
 }
 
 func (self *ModCook) SaveData() {
@@ -52,7 +51,7 @@ func (self *ModCook) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -30,7 +29,7 @@ func (self *ModHome) AddItem(itemId int, num int64) {
 	}
 	config := csvs.GetItemConfig(itemId)
 	if config != nil {
-		fmt.Println("获得家具物品", config.ItemName, "----数量：", num, "----当前数量：", self.HomeItemIdInfo[itemId].HomeItemNum)
+		self.player.Log().Infof("获得家具物品 %v ----数量： %v ----当前数量： %v", config.ItemName, num, self.HomeItemIdInfo[itemId].HomeItemNum)
 	}
 }
 
@@ -52,7 +51,7 @@ func (self *ModHome) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -27,16 +26,16 @@ func (self *ModIcon) IsHasIcon(iconId int) bool {
 func (self *ModIcon) AddItem(itemId int) {
 	_, ok := self.IconInfo[itemId]
 	if ok {
-		fmt.Println("已存在头像：", itemId)
+		self.player.Log().Warnf("已存在头像： %v", itemId)
 		return
 	}
 	config := csvs.GetIconConfig(itemId)
 	if config == nil {
-		fmt.Println("非法头像：", itemId)
+		self.player.Log().Warnf("非法头像： %v", itemId)
 		return
 	}
 	self.IconInfo[itemId] = &Icon{IconId: itemId}
-	fmt.Println("获得头像：", itemId)
+	self.player.Log().Infof("获得头像： %v", itemId)
 }
 
 func (self *ModIcon) CheckGetIcon(roleId int) {
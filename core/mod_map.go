@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"server-1.1.0/csvs"
+	"server-1.1.0/pb/pb"
 
 	"time"
 )
@@ -85,23 +86,23 @@ func (self *ModMap) GetEventList(config *csvs.ConfigMap) {
 		} else {
 			noticeTime = fmt.Sprintf("%d秒后刷新", lastTime)
 		}
-		fmt.Println(fmt.Sprintf("事件Id:%d,名字:%s,状态:%d,%s", v.EventId, csvs.GetEventName(v.EventId), v.State, noticeTime))
+		self.player.Log().Infof("事件Id:%d,名字:%s,状态:%d,%s", v.EventId, csvs.GetEventName(v.EventId), v.State, noticeTime)
 	}
 }
 
 func (self *ModMap) SetEventState(mapId int, eventId int, state int, player *Player) {
 	_, ok := self.MapInfo[mapId]
 	if !ok {
-		fmt.Println("地图不存在")
+		player.Log().Warn("地图不存在")
 		return
 	}
 	_, ok = self.MapInfo[mapId].EventInfo[eventId]
 	if !ok {
-		fmt.Println("事件不存在")
+		player.Log().Warn("事件不存在")
 		return
 	}
 	if self.MapInfo[mapId].EventInfo[eventId].State >= state {
-		fmt.Println("状态异常")
+		player.Log().Warn("状态异常")
 		return
 	}
 	eventConfig := csvs.GetEventConfig(self.MapInfo[mapId].EventInfo[eventId].EventId)
@@ -113,7 +114,7 @@ func (self *ModMap) SetEventState(mapId int, eventId int, state int, player *Pla
 		return
 	}
 	if !player.GetModBag().HasEnoughItem(eventConfig.CostItem, eventConfig.CostNum) {
-		fmt.Println(fmt.Sprintf("%s不足!", csvs.GetItemName(eventConfig.CostItem)))
+		player.Log().Warnf("%s不足!", csvs.GetItemName(eventConfig.CostItem))
 		return
 	}
 	if configMap.MapType == csvs.REFRESH_PLAYER && eventConfig.EventType == csvs.EVENT_TYPE_REWARD {
@@ -129,7 +130,7 @@ func (self *ModMap) SetEventState(mapId int, eventId int, state int, player *Pla
 				continue
 			}
 			if v.State != csvs.EVENT_END {
-				fmt.Println("有事件尚未完成:", v.EventId)
+				player.Log().Warnf("有事件尚未完成: %v", v.EventId)
 				return
 			}
 		}
@@ -137,7 +138,7 @@ func (self *ModMap) SetEventState(mapId int, eventId int, state int, player *Pla
 
 	self.MapInfo[mapId].EventInfo[eventId].State = state
 	if state == csvs.EVENT_FINISH {
-		fmt.Println("事件完成")
+		player.Log().Info("事件完成")
 	}
 	if state == csvs.EVENT_END {
 		for i := 0; i < eventConfig.EventDropTimes; i++ {
@@ -155,7 +156,7 @@ func (self *ModMap) SetEventState(mapId int, eventId int, state int, player *Pla
 				}
 			}
 		}
-		fmt.Println("事件领取")
+		player.Log().Info("事件领取")
 	}
 	if state > 0 {
 		switch eventConfig.RefreshType {
@@ -253,6 +254,20 @@ func (self *ModMap) RefreshByPlayer(mapId int) {
 	}
 }
 
+// GM重置地图的全部事件，不检查地图的刷新类型
+func (self *ModMap) ResetEventsGM(mapId int) error {
+	mapInfo, ok := self.MapInfo[mapId]
+	if !ok {
+		return NewGameError(pb.ErrorCode_InvalidParam, "地图不存在:%d", mapId)
+	}
+	for _, v := range mapInfo.EventInfo {
+		v.State = csvs.EVENT_START
+		v.NextResetTime = 0
+	}
+	self.player.Log().Infof("GM重置地图事件: %v", mapId)
+	return nil
+}
+
 func (self *ModMap) NewStatue(statueId int) *StatueInfo {
 	data := new(StatueInfo)
 	data.StatueId = statueId
@@ -285,7 +300,7 @@ func (self *ModMap) UpStatue(statueId int) {
 	if !self.player.GetMod(MOD_BAG).(*ModBag).HasEnoughItem(nextConfig.CostItem, needNum) {
 		num := self.player.GetMod(MOD_BAG).(*ModBag).GetItemNum(nextConfig.CostItem)
 		if num <= 0 {
-			fmt.Println(fmt.Sprintf("神像升级物品不足"))
+			self.player.Log().Warn("神像升级物品不足")
 			return
 		}
 		_, okItem := info.ItemInfo[nextConfig.CostItem]
@@ -300,13 +315,13 @@ func (self *ModMap) UpStatue(statueId int) {
 		}
 		info.ItemInfo[nextConfig.CostItem].ItemNum += num
 		self.player.GetModBag().RemoveItemToBag(nextConfig.CostItem, num)
-		fmt.Println(fmt.Sprintf("神像升级,提交物品%d，数量%d，当前数量%d", nextConfig.CostItem, num, info.ItemInfo[nextConfig.CostItem].ItemNum))
+		self.player.Log().Infof("神像升级,提交物品%d，数量%d，当前数量%d", nextConfig.CostItem, num, info.ItemInfo[nextConfig.CostItem].ItemNum)
 
 	} else {
 		self.player.GetModBag().RemoveItemToBag(nextConfig.CostItem, needNum)
 		info.Level++
 		info.ItemInfo = make(map[int]*ItemInfo)
-		fmt.Println(fmt.Sprintf("神像升级成功,神像:%d，当前等级:%d", info.StatueId, info.Level))
+		self.player.Log().Infof("神像升级成功,神像:%d，当前等级:%d", info.StatueId, info.Level)
 	}
 }
 
@@ -328,7 +343,8 @@ func (self *ModMap) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
+		self.InitData()
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -2,10 +2,10 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
+	"server-1.1.0/pb/pb"
 
 	"time"
 )
@@ -37,25 +37,26 @@ type ModPlayer struct {
 	path   string
 }
 
-func (self *ModPlayer) SetIcon(iconId int) {
+func (self *ModPlayer) SetIcon(iconId int) error {
 	if !self.player.GetModIcon().IsHasIcon(iconId) {
 		//通知客户端，操作非法
-		fmt.Println("没有头像:", iconId)
-		return
+		return NewGameError(pb.ErrorCode_IconNotOwned, "没有头像:%d", iconId)
 	}
 
 	self.Icon = iconId
-	fmt.Println("变更头像为:", csvs.GetItemName(iconId), self.Icon)
+	self.player.Log().Infof("变更头像为: %v %v", csvs.GetItemName(iconId), self.Icon)
+	return nil
 }
 
-func (self *ModPlayer) SetCard(cardId int) {
+func (self *ModPlayer) SetCard(cardId int) error {
 	if !self.player.GetModCard().IsHasCard(cardId) {
 		//通知客户端，操作非法
-		return
+		return NewGameError(pb.ErrorCode_CardNotOwned, "没有名片:%d", cardId)
 	}
 
 	self.Card = cardId
-	fmt.Println("当前名片", self.Card)
+	self.player.Log().Infof("当前名片 %v", self.Card)
+	return nil
 }
 
 func (self *ModPlayer) SetName(name string) {
@@ -67,7 +68,7 @@ func (self *ModPlayer) SetName(name string) {
 func (self *ModPlayer) SetSign(sign string) {
 
 	self.Sign = sign
-	fmt.Println("设置成功,签名变更为:", self.Sign)
+	self.player.Log().Infof("设置成功,签名变更为: %v", self.Sign)
 }
 
 func (self *ModPlayer) AddExp(exp int, player *Player) {
@@ -91,52 +92,46 @@ func (self *ModPlayer) AddExp(exp int, player *Player) {
 			break
 		}
 	}
-	fmt.Println("当前等级:", self.PlayerLevel, "---当前经验：", self.PlayerExp)
+	player.Log().Infof("当前等级: %v ---当前经验： %v", self.PlayerLevel, self.PlayerExp)
 }
 
-func (self *ModPlayer) ReduceWorldLevel() {
+func (self *ModPlayer) ReduceWorldLevel() error {
 	if self.WorldLevel < csvs.REDUCE_WORLD_LEVEL_START {
-		fmt.Println("操作失败:, ---当前世界等级：", self.WorldLevel)
-		return
+		return NewGameError(pb.ErrorCode_WorldLevelLimit, "操作失败:, ---当前世界等级：%d", self.WorldLevel)
 	}
 
 	if self.WorldLevel-self.WorldLevelNow >= csvs.REDUCE_WORLD_LEVEL_MAX {
-		fmt.Println("操作失败:, ---当前世界等级：", self.WorldLevel, "---真实世界等级：", self.WorldLevelNow)
-		return
+		return NewGameError(pb.ErrorCode_WorldLevelLimit, "操作失败:, ---当前世界等级：%d ---真实世界等级：%d", self.WorldLevel, self.WorldLevelNow)
 	}
 
 	if time.Now().Unix() < self.WorldLevelCool {
-		fmt.Println("操作失败:, ---冷却中")
-		return
+		return NewGameError(pb.ErrorCode_CoolDown, "操作失败:, ---冷却中")
 	}
 
 	self.WorldLevelNow -= 1
 	self.WorldLevelCool = time.Now().Unix() + csvs.REDUCE_WORLD_LEVEL_COOL_TIME
-	fmt.Println("操作成功:, ---当前世界等级：", self.WorldLevel, "---真实世界等级：", self.WorldLevelNow)
-	return
+	self.player.Log().Infof("操作成功:, ---当前世界等级： %v ---真实世界等级： %v", self.WorldLevel, self.WorldLevelNow)
+	return nil
 }
 
-func (self *ModPlayer) ReturnWorldLevel() {
+func (self *ModPlayer) ReturnWorldLevel() error {
 	if self.WorldLevelNow == self.WorldLevel {
-		fmt.Println("操作失败:, ---当前世界等级：", self.WorldLevel, "---真实世界等级：", self.WorldLevelNow)
-		return
+		return NewGameError(pb.ErrorCode_WorldLevelLimit, "操作失败:, ---当前世界等级：%d ---真实世界等级：%d", self.WorldLevel, self.WorldLevelNow)
 	}
 
 	if time.Now().Unix() < self.WorldLevelCool {
-		fmt.Println("操作失败:, ---冷却中")
-		return
+		return NewGameError(pb.ErrorCode_CoolDown, "操作失败:, ---冷却中")
 	}
 
 	self.WorldLevelNow += 1
 	self.WorldLevelCool = time.Now().Unix() + csvs.REDUCE_WORLD_LEVEL_COOL_TIME
-	fmt.Println("操作成功:, ---当前世界等级：", self.WorldLevel, "---真实世界等级：", self.WorldLevelNow)
-	return
+	self.player.Log().Infof("操作成功:, ---当前世界等级： %v ---真实世界等级： %v", self.WorldLevel, self.WorldLevelNow)
+	return nil
 }
 
-func (self *ModPlayer) SetBirth(birth int) {
+func (self *ModPlayer) SetBirth(birth int) error {
 	if self.Birth > 0 {
-		fmt.Println("已设置过生日!")
-		return
+		return NewGameError(pb.ErrorCode_BirthAlreadySet, "已设置过生日!")
 	}
 
 	month := birth / 100
@@ -145,33 +140,29 @@ func (self *ModPlayer) SetBirth(birth int) {
 	switch month {
 	case 1, 3, 5, 7, 8, 10, 12:
 		if day <= 0 || day > 31 {
-			fmt.Println(month, "月没有", day, "日！")
-			return
+			return NewGameError(pb.ErrorCode_InvalidParam, "%d月没有%d日！", month, day)
 		}
 	case 4, 6, 9, 11:
 		if day <= 0 || day > 30 {
-			fmt.Println(month, "月没有", day, "日！")
-			return
+			return NewGameError(pb.ErrorCode_InvalidParam, "%d月没有%d日！", month, day)
 		}
 	case 2:
 		if day <= 0 || day > 29 {
-			fmt.Println(month, "月没有", day, "日！")
-			return
+			return NewGameError(pb.ErrorCode_InvalidParam, "%d月没有%d日！", month, day)
 		}
 	default:
-		fmt.Println("没有", month, "月！")
-		return
+		return NewGameError(pb.ErrorCode_InvalidParam, "没有%d月！", month)
 	}
 
 	self.Birth = birth
-	fmt.Println("设置成功，生日为:", month, "月", day, "日")
+	self.player.Log().Infof("设置成功，生日为: %v 月 %v 日", month, day)
 
 	if self.IsBirthDay() {
-		fmt.Println("今天是你的生日，生日快乐！")
+		self.player.Log().Info("今天是你的生日，生日快乐！")
 	} else {
-		fmt.Println("期待你生日的到来!")
+		self.player.Log().Info("期待你生日的到来!")
 	}
-
+	return nil
 }
 
 func (self *ModPlayer) IsBirthDay() bool {
@@ -183,10 +174,10 @@ func (self *ModPlayer) IsBirthDay() bool {
 	return false
 }
 
-func (self *ModPlayer) SetShowCard(showCard []int, player *Player) {
+func (self *ModPlayer) SetShowCard(showCard []int, player *Player) error {
 
 	if len(showCard) > csvs.SHOW_SIZE {
-		return
+		return NewGameError(pb.ErrorCode_InvalidParam, "展示名片数量超过上限:%d", len(showCard))
 	}
 
 	cardExist := make(map[int]int)
@@ -203,13 +194,13 @@ func (self *ModPlayer) SetShowCard(showCard []int, player *Player) {
 		cardExist[cardId] = 1
 	}
 	self.ShowCard = newList
-	fmt.Println(self.ShowCard)
+	player.Log().Info("设置展示名片", "showCard", self.ShowCard)
+	return nil
 }
 
-func (self *ModPlayer) SetShowTeam(showRole []int, player *Player) {
+func (self *ModPlayer) SetShowTeam(showRole []int, player *Player) error {
 	if len(showRole) > csvs.SHOW_SIZE {
-		fmt.Println("消息结构错误")
-		return
+		return NewGameError(pb.ErrorCode_InvalidParam, "消息结构错误")
 	}
 
 	roleExist := make(map[int]int)
@@ -229,14 +220,16 @@ func (self *ModPlayer) SetShowTeam(showRole []int, player *Player) {
 		roleExist[roleId] = 1
 	}
 	self.ShowTeam = newList
-	fmt.Println(self.ShowCard)
+	player.Log().Info("设置展示阵容", "showTeam", len(self.ShowTeam))
+	return nil
 }
 
-func (self *ModPlayer) SetHideShowTeam(isHide int, player *Player) {
+func (self *ModPlayer) SetHideShowTeam(isHide int, player *Player) error {
 	if isHide != csvs.LOGIC_FALSE && isHide != csvs.LOGIC_TRUE {
-		return
+		return NewGameError(pb.ErrorCode_InvalidParam, "隐藏开关错误:%d", isHide)
 	}
 	self.HideShowTeam = isHide
+	return nil
 }
 
 func (self *ModPlayer) SetProhibit(prohibit int) {
@@ -247,6 +240,32 @@ func (self *ModPlayer) SetIsGM(isGm int) {
 	self.IsGM = isGm
 }
 
+// GM设置等级，经验清零
+func (self *ModPlayer) SetPlayerLevelGM(level int) error {
+	if csvs.GetNowLevelConfig(level) == nil {
+		return NewGameError(pb.ErrorCode_InvalidParam, "等级不存在:%d", level)
+	}
+	self.PlayerLevel = level
+	self.PlayerExp = 0
+	self.player.Log().Infof("GM设置等级: %v", self.PlayerLevel)
+	return nil
+}
+
+// GM设置世界等级，当前世界等级一起修改，不受冷却时间限制
+func (self *ModPlayer) SetWorldLevelGM(level int) error {
+	if level < 0 || level > csvs.GetMaxWorldLevel() {
+		return NewGameError(pb.ErrorCode_WorldLevelLimit, "世界等级需要在0到%d之间", csvs.GetMaxWorldLevel())
+	}
+	self.WorldLevel = level
+	self.WorldLevelNow = level
+	self.player.Log().Infof("GM设置世界等级: %v", self.WorldLevel)
+	return nil
+}
+
+func (self *ModPlayer) IsGMAccount() bool {
+	return self.IsGM == csvs.LOGIC_TRUE
+}
+
 func (self *ModPlayer) IsCanEnter() bool {
 	return int64(self.Prohibit) < time.Now().Unix()
 }
@@ -281,7 +300,7 @@ func (self *ModPlayer) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -87,7 +86,7 @@ func (self *ModPool) DoUpPool() {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								self.player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -117,17 +116,17 @@ func (self *ModPool) DoUpPool() {
 	}
 
 	for k, v := range result {
-		fmt.Println(fmt.Sprintf("抽中%s次数：%d", csvs.GetItemName(k), v))
+		self.player.Log().Infof("抽中%s次数：%d", csvs.GetItemName(k), v)
 	}
-	fmt.Println(fmt.Sprintf("抽中4星角色：%d", fourNum))
-	fmt.Println(fmt.Sprintf("抽中5星：%d", fiveNum))
+	self.player.Log().Infof("抽中4星角色：%d", fourNum)
+	self.player.Log().Infof("抽中5星：%d", fiveNum)
 
 	for k, v := range resultEach {
-		fmt.Println(fmt.Sprintf("第%d抽抽出5星的次数：%d", k, v))
+		self.player.Log().Infof("第%d抽抽出5星的次数：%d", k, v)
 	}
 
 	for k, v := range resultEachTest {
-		fmt.Println(fmt.Sprintf("10连%d黄次数：%d", k, v))
+		self.player.Log().Infof("10连%d黄次数：%d", k, v)
 	}
 }
 
@@ -179,7 +178,7 @@ func (self *ModPool) HandleUpPoolTen(player *Player) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -198,21 +197,21 @@ func (self *ModPool) HandleUpPoolTen(player *Player) {
 		}
 	}
 	if self.UpPoolInfo.IsMustUp == csvs.LOGIC_FALSE {
-		fmt.Println(fmt.Sprintf("当前处于小保底区间！"))
+		player.Log().Info("当前处于小保底区间！")
 	} else {
-		fmt.Println(fmt.Sprintf("当前处于大保底区间！"))
+		player.Log().Info("当前处于大保底区间！")
 	}
-	fmt.Println(fmt.Sprintf("当前累计未出5星次数：%d", self.UpPoolInfo.FiveStarTimes))
-	fmt.Println(fmt.Sprintf("当前累计未出4星次数：%d", self.UpPoolInfo.FourStarTimes))
+	player.Log().Infof("当前累计未出5星次数：%d", self.UpPoolInfo.FiveStarTimes)
+	player.Log().Infof("当前累计未出4星次数：%d", self.UpPoolInfo.FourStarTimes)
 
 }
 
 func (self *ModPool) HandleUpPoolSingle(times int, player *Player) {
 	if times <= 0 || times > 100000000 {
-		fmt.Println("请输入正确的数值(1~100000000)")
+		player.Log().Info("请输入正确的数值(1~100000000)")
 		return
 	} else {
-		fmt.Println(fmt.Sprintf("累计抽取%d次,结果如下:", times))
+		player.Log().Infof("累计抽取%d次,结果如下:", times)
 	}
 	result := make(map[int]int)
 	fourNum := 0
@@ -265,7 +264,7 @@ func (self *ModPool) HandleUpPoolSingle(times int, player *Player) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -293,18 +292,18 @@ func (self *ModPool) HandleUpPoolSingle(times int, player *Player) {
 	}
 
 	for k, v := range result {
-		fmt.Println(fmt.Sprintf("抽中%s次数：%d", csvs.GetItemName(k), v))
+		player.Log().Infof("抽中%s次数：%d", csvs.GetItemName(k), v)
 	}
-	fmt.Println(fmt.Sprintf("抽中4星角色：%d", fourNum))
-	fmt.Println(fmt.Sprintf("抽中5星：%d", fiveNum))
+	player.Log().Infof("抽中4星角色：%d", fourNum)
+	player.Log().Infof("抽中5星：%d", fiveNum)
 }
 
 func (self *ModPool) HandleUpPoolTimesTest(times int) {
 	if times <= 0 || times > 100000000 {
-		fmt.Println("请输入正确的数值(1~100000000)")
+		self.player.Log().Info("请输入正确的数值(1~100000000)")
 		return
 	} else {
-		fmt.Println(fmt.Sprintf("累计抽取%d次,结果如下:", times))
+		self.player.Log().Infof("累计抽取%d次,结果如下:", times)
 	}
 	resultEach := make(map[int]int)
 	for i := 0; i < times; i++ {
@@ -355,7 +354,7 @@ func (self *ModPool) HandleUpPoolTimesTest(times int) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								self.player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -373,16 +372,16 @@ func (self *ModPool) HandleUpPoolTimesTest(times int) {
 	}
 
 	for k, v := range resultEach {
-		fmt.Println(fmt.Sprintf("第%d抽抽出5星的次数：%d", k, v))
+		self.player.Log().Infof("第%d抽抽出5星的次数：%d", k, v)
 	}
 }
 
 func (self *ModPool) HandleUpPoolFiveTest(times int) {
 	if times <= 0 || times > 100000000 {
-		fmt.Println("请输入正确的数值(1~100000000)")
+		self.player.Log().Info("请输入正确的数值(1~100000000)")
 		return
 	} else {
-		fmt.Println(fmt.Sprintf("累计抽取%d次,结果如下:", times))
+		self.player.Log().Infof("累计抽取%d次,结果如下:", times)
 	}
 	resultEachTest := make(map[int]int)
 	fiveTest := 0
@@ -437,7 +436,7 @@ func (self *ModPool) HandleUpPoolFiveTest(times int) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								self.player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -458,16 +457,16 @@ func (self *ModPool) HandleUpPoolFiveTest(times int) {
 	}
 
 	for k, v := range resultEachTest {
-		fmt.Println(fmt.Sprintf("10连%d黄次数：%d", k, v))
+		self.player.Log().Infof("10连%d黄次数：%d", k, v)
 	}
 }
 
 func (self *ModPool) HandleUpPoolSingleCheck1(times int, player *Player) {
 	if times <= 0 || times > 100000000 {
-		fmt.Println("请输入正确的数值(1~100000000)")
+		player.Log().Info("请输入正确的数值(1~100000000)")
 		return
 	} else {
-		fmt.Println(fmt.Sprintf("累计抽取%d次,结果如下:", times))
+		player.Log().Infof("累计抽取%d次,结果如下:", times)
 	}
 	result := make(map[int]int)
 	fourNum := 0
@@ -522,7 +521,7 @@ func (self *ModPool) HandleUpPoolSingleCheck1(times int, player *Player) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -550,18 +549,18 @@ func (self *ModPool) HandleUpPoolSingleCheck1(times int, player *Player) {
 	}
 
 	for k, v := range result {
-		fmt.Println(fmt.Sprintf("抽中%s次数：%d", csvs.GetItemName(k), v))
+		player.Log().Infof("抽中%s次数：%d", csvs.GetItemName(k), v)
 	}
-	fmt.Println(fmt.Sprintf("抽中4星角色：%d", fourNum))
-	fmt.Println(fmt.Sprintf("抽中5星：%d", fiveNum))
+	player.Log().Infof("抽中4星角色：%d", fourNum)
+	player.Log().Infof("抽中5星：%d", fiveNum)
 }
 
 func (self *ModPool) HandleUpPoolSingleCheck2(times int, player *Player) {
 	if times <= 0 || times > 100000000 {
-		fmt.Println("请输入正确的数值(1~100000000)")
+		player.Log().Info("请输入正确的数值(1~100000000)")
 		return
 	} else {
-		fmt.Println(fmt.Sprintf("累计抽取%d次,结果如下:", times))
+		player.Log().Infof("累计抽取%d次,结果如下:", times)
 	}
 	result := make(map[int]int)
 	fourNum := 0
@@ -616,7 +615,7 @@ func (self *ModPool) HandleUpPoolSingleCheck2(times int, player *Player) {
 						if dropGroup != nil {
 							roleIdConfig = csvs.GetRandDropNew(dropGroup)
 							if roleIdConfig == nil {
-								fmt.Println("数据异常")
+								player.Log().Warn("数据异常")
 								return
 							}
 						}
@@ -644,10 +643,10 @@ func (self *ModPool) HandleUpPoolSingleCheck2(times int, player *Player) {
 	}
 
 	for k, v := range result {
-		fmt.Println(fmt.Sprintf("抽中%s次数：%d", csvs.GetItemName(k), v))
+		player.Log().Infof("抽中%s次数：%d", csvs.GetItemName(k), v)
 	}
-	fmt.Println(fmt.Sprintf("抽中4星角色：%d", fourNum))
-	fmt.Println(fmt.Sprintf("抽中5星：%d", fiveNum))
+	player.Log().Infof("抽中4星角色：%d", fourNum)
+	player.Log().Infof("抽中5星：%d", fiveNum)
 }
 
 func (self *ModPool) SaveData() {
@@ -669,7 +668,7 @@ func (self *ModPool) LoadData(player *Player) {
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
 		self.InitData()
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
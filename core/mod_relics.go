@@ -2,11 +2,11 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"math/rand"
 	"os"
 	"server-1.1.0/csvs"
+	"server-1.1.0/network/zlog"
 )
 
 type Relics struct {
@@ -31,19 +31,19 @@ func (self *ModRelics) AddItem(itemId int, num int64) {
 
 	config := csvs.GetRelicsConfig(itemId)
 	if config == nil {
-		fmt.Println("配置不存在")
+		self.player.Log().Warn("配置不存在")
 		return
 	}
 
 	if len(self.RelicsInfo)+int(num) > csvs.RELICS_MAX_COUNT {
-		fmt.Println("超过最大值")
+		self.player.Log().Warn("超过最大值")
 		return
 	}
 
 	for i := int64(0); i < num; i++ {
 		relics := self.NewRelice(itemId)
 		self.RelicsInfo[relics.KeyId] = relics
-		fmt.Println("获得圣遗物:")
+		self.player.Log().Info("获得圣遗物:")
 		relics.ShowInfo()
 	}
 }
@@ -167,16 +167,16 @@ func (self *ModRelics) MakeOtherEntry(relics *Relics, otherGroup int) int {
 }
 
 func (self *Relics) ShowInfo() {
-	fmt.Println(fmt.Sprintf("key:%d,Id:%d", self.KeyId, self.RelicsId))
-	fmt.Println(fmt.Sprintf("当前等级:%d,当前经验:%d", self.Level, self.Exp))
+	zlog.Infof("key:%d,Id:%d", self.KeyId, self.RelicsId)
+	zlog.Infof("当前等级:%d,当前经验:%d", self.Level, self.Exp)
 	mainEntryConfig := csvs.GetReliceLevelConfig(self.MainEntry, self.Level)
 	if mainEntryConfig != nil {
-		fmt.Println(fmt.Sprintf("主词条属性:%s,值:%d", mainEntryConfig.AttrName, mainEntryConfig.AttrValue))
+		zlog.Infof("主词条属性:%s,值:%d", mainEntryConfig.AttrName, mainEntryConfig.AttrValue)
 	}
 	for _, v := range self.OtherEntry {
 		otherEntryConfig := csvs.ConfigRelicsEntryMap[v]
 		if otherEntryConfig != nil {
-			fmt.Println(fmt.Sprintf("副词条属性:%s,值:%d", otherEntryConfig.AttrName, otherEntryConfig.AttrValue))
+			zlog.Infof("副词条属性:%s,值:%d", otherEntryConfig.AttrName, otherEntryConfig.AttrValue)
 		}
 	}
 }
@@ -184,7 +184,7 @@ func (self *Relics) ShowInfo() {
 func (self *ModRelics) RelicsUp(player *Player) {
 	relics := self.RelicsInfo[1]
 	if relics == nil {
-		fmt.Println("找不到对应圣遗物")
+		player.Log().Warn("找不到对应圣遗物")
 		return
 	}
 	relics.Exp += 100000
@@ -267,7 +267,7 @@ func (self *ModRelics) RelicsTestBest(player *Player) {
 		}
 		relicsBestInfo = append(relicsBestInfo, relics)
 	}
-	fmt.Println(fmt.Sprintf("生成了圣遗物头部位%d个，极品数量%d", allTimes, len(relicsBestInfo)))
+	player.Log().Infof("生成了圣遗物头部位%d个，极品数量%d", allTimes, len(relicsBestInfo))
 
 	for _, v := range relicsBestInfo {
 		v.ShowInfo()
@@ -292,7 +292,7 @@ func (self *ModRelics) LoadData(player *Player) {
 
 	configFile, err := ioutil.ReadFile(self.path)
 	if err != nil {
-		fmt.Println("error")
+		player.Log().Debug("load data error", "path", self.path, "err", err)
 		return
 	}
 	err = json.Unmarshal(configFile, &self)
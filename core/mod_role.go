@@ -2,7 +2,6 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
@@ -37,7 +36,7 @@ func (self *ModRole) GetRoleLevel(roleId int) int {
 func (self *ModRole) AddItem(roleId int, num int64) {
 	config := csvs.GetRoleConfig(roleId)
 	if config == nil {
-		fmt.Println("配置不存在roleId:", roleId)
+		self.player.Log().Warnf("配置不存在roleId: %v", roleId)
 		return
 	}
 	for i := 0; i < int(num); i++ {
@@ -61,21 +60,21 @@ func (self *ModRole) AddItem(roleId int, num int64) {
 	}
 	itemConfig := csvs.GetItemConfig(roleId)
 	if itemConfig != nil {
-		fmt.Println("获得角色", itemConfig.ItemName, "次数", roleId, "------", self.RoleInfo[roleId].GetTimes, "次")
+		self.player.Log().Infof("获得角色 %v 次数 %v ------ %v 次", itemConfig.ItemName, roleId, self.RoleInfo[roleId].GetTimes)
 	}
 	self.player.GetModIcon().CheckGetIcon(roleId)
 	self.player.GetModCard().CheckGetCard(roleId, 10)
 }
 
 func (self *ModRole) HandleSendRoleInfo(player *Player) {
-	fmt.Println(fmt.Sprintf("当前拥有角色信息如下:"))
+	player.Log().Info("当前拥有角色信息如下:")
 	for _, v := range self.RoleInfo {
 		v.SendRoleInfo(player)
 	}
 }
 
 func (self *RoleInfo) SendRoleInfo(player *Player) {
-	fmt.Println(fmt.Sprintf("%s:,Id:%d,累计获得次数:%d", csvs.GetItemName(self.RoleId), self.RoleId, self.GetTimes))
+	player.Log().Infof("%s:,Id:%d,累计获得次数:%d", csvs.GetItemName(self.RoleId), self.RoleId, self.GetTimes)
 	self.ShowInfo(player)
 }
 
@@ -104,7 +103,7 @@ func (self *ModRole) CalHpPool() {
 	calTime := time.Now().Unix() - self.HpCalTime
 	self.HpPool += int(calTime) * 10
 	self.HpCalTime = time.Now().Unix()
-	fmt.Println("当前血池回复量:", self.HpPool)
+	self.player.Log().Infof("当前血池回复量: %v", self.HpPool)
 }
 
 // 把圣遗物穿在角色身上
@@ -160,23 +159,23 @@ func (self *ModRole) CheckRelicsPos(roleInfo *RoleInfo, pos int) {
 }
 
 func (self *RoleInfo) ShowInfo(player *Player) {
-	fmt.Println(fmt.Sprintf("当前角色:%s,角色ID:%d", csvs.GetItemName(self.RoleId), self.RoleId))
+	player.Log().Infof("当前角色:%s,角色ID:%d", csvs.GetItemName(self.RoleId), self.RoleId)
 
 	weaponNow := player.GetModWeapon().WeaponInfo[self.WeaponInfo]
 	if weaponNow == nil {
-		fmt.Println(fmt.Sprintf("武器:未穿戴"))
+		player.Log().Info("武器:未穿戴")
 	} else {
-		fmt.Println(fmt.Sprintf("武器:%s,key:%d", csvs.GetItemName(weaponNow.WeaponId), self.WeaponInfo))
+		player.Log().Infof("武器:%s,key:%d", csvs.GetItemName(weaponNow.WeaponId), self.WeaponInfo)
 	}
 
 	suitMap := make(map[int]int)
 	for _, v := range self.RelicsInfo {
 		relicsNow := player.GetModRelics().RelicsInfo[v]
 		if relicsNow == nil {
-			fmt.Println(fmt.Sprintf("未穿戴"))
+			player.Log().Info("未穿戴")
 			continue
 		}
-		fmt.Println(fmt.Sprintf("%s,key:%d", csvs.GetItemName(relicsNow.RelicsId), v))
+		player.Log().Infof("%s,key:%d", csvs.GetItemName(relicsNow.RelicsId), v)
 		relicsNowConfig := csvs.GetRelicsConfig(relicsNow.RelicsId)
 		if relicsNowConfig != nil {
 			suitMap[relicsNowConfig.Type]++
@@ -192,7 +191,7 @@ func (self *RoleInfo) ShowInfo(player *Player) {
 		}
 	}
 	for _, v := range suitSkill {
-		fmt.Println(fmt.Sprintf("激活套装效果:%d", v))
+		player.Log().Infof("激活套装效果:%d", v)
 	}
 
 }
@@ -207,7 +206,7 @@ func (self *ModRole) TakeOffRelics(roleInfo *RoleInfo, relics *Relics, player *P
 		return
 	}
 	if roleInfo.RelicsInfo[relicsConfig.Pos-1] != relics.KeyId {
-		fmt.Println(fmt.Sprintf("当前角色没有穿戴这个物品"))
+		player.Log().Warn("当前角色没有穿戴这个物品")
 		return
 	}
 
@@ -219,14 +218,14 @@ func (self *ModRole) TakeOffRelics(roleInfo *RoleInfo, relics *Relics, player *P
 func (self *ModRole) WearWeapon(roleInfo *RoleInfo, weapon *Weapon, player *Player) {
 	weaponConfig := csvs.GetWeaponConfig(weapon.WeaponId)
 	if weaponConfig == nil {
-		fmt.Println("数据异常，武器配置不存在")
+		player.Log().Warn("数据异常，武器配置不存在")
 		return
 	}
 
 	//先判断武器和角色是否匹配
 	roleConfig := csvs.GetRoleConfig(roleInfo.RoleId)
 	if roleConfig.Type != weaponConfig.Type {
-		fmt.Println("武器和角色不匹配")
+		player.Log().Warn("武器和角色不匹配")
 		return
 	}
 
@@ -265,11 +264,11 @@ func (self *ModRole) WearWeapon(roleInfo *RoleInfo, weapon *Weapon, player *Play
 func (self *ModRole) TakeOffWeapon(roleInfo *RoleInfo, weapon *Weapon, player *Player) {
 	weaponConfig := csvs.GetWeaponConfig(weapon.WeaponId)
 	if weaponConfig == nil {
-		fmt.Println("数据异常，武器配置不存在")
+		player.Log().Warn("数据异常，武器配置不存在")
 		return
 	}
 	if roleInfo.WeaponInfo != weapon.KeyId {
-		fmt.Println("角色没有装备这把武器")
+		player.Log().Warn("角色没有装备这把武器")
 		return
 	}
 	//根据位置看是否身上有对应圣遗物
@@ -2,10 +2,10 @@ package core
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
 	"server-1.1.0/csvs"
+	"server-1.1.0/network/zlog"
 )
 
 type Weapon struct {
@@ -30,12 +30,12 @@ func (self *ModWeapon) AddItem(itemId int, num int64) {
 
 	config := csvs.GetWeaponConfig(itemId)
 	if config == nil {
-		fmt.Println("配置不存在")
+		self.player.Log().Warn("配置不存在")
 		return
 	}
 
 	if len(self.WeaponInfo)+int(num) > csvs.WEAPON_MAX_COUNT {
-		fmt.Println("超过最大值")
+		self.player.Log().Warn("超过最大值")
 		return
 	}
 
@@ -45,7 +45,7 @@ func (self *ModWeapon) AddItem(itemId int, num int64) {
 		self.MaxKey++
 		weapon.KeyId = self.MaxKey
 		self.WeaponInfo[weapon.KeyId] = weapon
-		fmt.Println("获得武器:", csvs.GetItemName(itemId), "------武器编号:", weapon.KeyId)
+		self.player.Log().Infof("获得武器: %v ------武器编号: %v", csvs.GetItemName(itemId), weapon.KeyId)
 	}
 }
 
@@ -62,12 +62,12 @@ func (self *ModWeapon) WeaponUp(keyId int, player *Player) {
 	for {
 		nextLevelConfig := csvs.GetWeaponLevelConfig(weaponConfig.Star, weapon.Level+1)
 		if nextLevelConfig == nil {
-			fmt.Println("返还武器经验:", weapon.Exp)
+			player.Log().Infof("返还武器经验: %v", weapon.Exp)
 			weapon.Exp = 0
 			break
 		}
 		if weapon.StarLevel < nextLevelConfig.NeedStarLevel {
-			fmt.Println("返还武器经验:", weapon.Exp)
+			player.Log().Infof("返还武器经验: %v", weapon.Exp)
 			weapon.Exp = 0
 			break
 		}
@@ -81,9 +81,9 @@ func (self *ModWeapon) WeaponUp(keyId int, player *Player) {
 }
 
 func (self *Weapon) ShowInfo() {
-	fmt.Println(fmt.Sprintf("key:%d,Id:%d", self.KeyId, self.WeaponId))
-	fmt.Println(fmt.Sprintf("当前等级:%d,当前经验:%d,当前突破等级:%d,当前精炼等级:%d",
-		self.Level, self.Exp, self.StarLevel, self.RefineLevel))
+	zlog.Infof("key:%d,Id:%d", self.KeyId, self.WeaponId)
+	zlog.Infof("当前等级:%d,当前经验:%d,当前突破等级:%d,当前精炼等级:%d",
+		self.Level, self.Exp, self.StarLevel, self.RefineLevel)
 }
 
 func (self *ModWeapon) WeaponUpStar(keyId int, player *Player) {
@@ -102,7 +102,7 @@ func (self *ModWeapon) WeaponUpStar(keyId int, player *Player) {
 	//验证物品充足并扣除
 	//........
 	if weapon.Level < nextStarConfig.Level {
-		fmt.Println("武器等级不够，无法突破")
+		player.Log().Warn("武器等级不够，无法突破")
 		return
 	}
 	weapon.StarLevel++
@@ -111,7 +111,7 @@ func (self *ModWeapon) WeaponUpStar(keyId int, player *Player) {
 
 func (self *ModWeapon) WeaponUpRefine(keyId int, targetKeyId int, player *Player) {
 	if keyId == targetKeyId {
-		fmt.Println("错误的材料")
+		player.Log().Warn("错误的材料")
 		return
 	}
 	weapon := self.WeaponInfo[keyId]
@@ -123,11 +123,11 @@ func (self *ModWeapon) WeaponUpRefine(keyId int, targetKeyId int, player *Player
 		return
 	}
 	if weapon.WeaponId != weaponTarget.WeaponId {
-		fmt.Println("错误的材料")
+		player.Log().Warn("错误的材料")
 		return
 	}
 	if weapon.RefineLevel >= csvs.WEAPON_MAX_REFINE {
-		fmt.Println("超过了最大精炼等级")
+		player.Log().Warn("超过了最大精炼等级")
 		return
 	}
 	weapon.RefineLevel++
@@ -8,6 +8,7 @@ import (
 	"server-1.1.0/csvs"
 	"server-1.1.0/network/utils"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
 	"server-1.1.0/pb/pb"
 
 	"sync"
@@ -40,35 +41,27 @@ type ModBase interface {
 	InitData()
 }
 
-var player *Player
-
 // 玩家对象
 type Player struct {
 	//玩家ID
 	UserId    int32
 	ModManage map[string]ModBase
 	localPath string
-	//当前玩家用于和客户端的连接
+	//当前玩家用于和客户端的连接，断线等待重连时为nil，重连之后绑定新的链接
 	Conn ziface.IConnection
 	X    float32 //平面x坐标
 	Y    float32 //高度
 	Z    float32 //平面y坐标
 	V    float32 //旋转0-360角度
-}
 
-// player id 生成器 后面生成数据库
-// 应该有登录模块，由数据库查询之后再发入ID等信息
-var PidGen int32 = 1
-var IDLock sync.Mutex
+	//保护Conn，断线和重连时修改
+	connLock sync.RWMutex
+}
 
-func NewPlayer(conn ziface.IConnection) *Player {
-	//生成玩家id
-	IDLock.Lock()
-	id := PidGen
-	PidGen++
-	IDLock.Unlock()
-	player = new(Player)
-	player.ModManage = map[string]ModBase{
+// 创建玩家并加载存档，玩家ID由登录的账号决定，见AccountManager
+func NewPlayer(conn ziface.IConnection, id int32) *Player {
+	p := new(Player)
+	p.ModManage = map[string]ModBase{
 		MOD_PLAYER:     new(ModPlayer),
 		MOD_ICON:       new(ModIcon),
 		MOD_CARD:       new(ModCard),
@@ -92,22 +85,22 @@ func NewPlayer(conn ziface.IConnection) *Player {
 	//	Z: float32(140 + rand.Intn(20)),
 	//	V: 0,
 	//}
-	player.UserId = id
-	player.Conn = conn
-	player.X = float32(160 + rand.Intn(10))
-	player.Y = 0
-	player.Z = float32(140 + rand.Intn(20))
-	player.V = 0
-	player.InitData()
-	player.InitMod()
+	p.UserId = id
+	p.Conn = conn
+	p.X = float32(160 + rand.Intn(10))
+	p.Y = 0
+	p.Z = float32(140 + rand.Intn(20))
+	p.V = 0
+	p.InitData()
+	p.InitMod()
 
-	return player
+	return p
 
 }
 
 func (self *Player) InitData() {
 	//path := GetServer().Config.LocalSavePath
-	path := utils.GlobalObject.LocalSavePath
+	path := utils.Global().LocalSavePath
 	_, err := os.Stat(path)
 	if err != nil {
 		err = os.Mkdir(path, os.ModePerm)
@@ -131,33 +124,97 @@ func (self *Player) InitMod() {
 	}
 }
 
+// 保存玩家所有模块的数据
+func (self *Player) SaveData() {
+	for _, v := range self.ModManage {
+		v.SaveData()
+	}
+}
+
 // 提供一个发送给客户端消息的方法
-// 主要是将pb的protobuf数据序列化之后，再调用zinx的SendMsg方法
+// 主要是将pb的protobuf数据序列化之后，再调用zinx的SendBuffMsg方法
+// 使用带缓冲的发送，不会因为对方客户端接收慢而阻塞当前的业务处理
 func (p *Player) SendMsg(msgId uint32, data proto.Message) {
-	if p.Conn == nil {
-		fmt.Println("connection in player is nil")
+	p.sendMsg(msgId, data, ziface.IConnection.SendBuffMsg)
+}
+
+// 发送可以丢弃的消息，对方发送队列满时丢弃最旧的消息
+func (p *Player) SendDroppableMsg(msgId uint32, data proto.Message) {
+	p.sendMsg(msgId, data, ziface.IConnection.SendDroppableMsg)
+}
+
+// 通过UDP发送可以丢弃的消息(例如移动广播)，UDP通道不可用时同SendDroppableMsg
+func (p *Player) SendUnreliableMsg(msgId uint32, data proto.Message) {
+	p.sendMsg(msgId, data, ziface.IConnection.SendUnreliableMsg)
+}
+
+func (p *Player) sendMsg(msgId uint32, data proto.Message, send func(conn ziface.IConnection, msgId uint32, data []byte) error) {
+	conn := p.GetConn()
+	if conn == nil {
+		//断线等待重连，重连之后重新同步
+		p.Log().Debug("player is disconnected, drop msg", "msgID", msgId)
 		return
 	}
 	//将proto Msg结构体序列化 转换为2进制
 	msg, err := proto.Marshal(data)
 	if err != nil {
-		fmt.Println()
+		p.Log().Error("marshal error", "msgID", msgId, "err", err)
+		return
 	}
-	if err != nil {
-		fmt.Println("Marshal err: ", err)
+	//将二进制文件通过zinx框架将数据发送给客户端
+	if err := send(conn, msgId, msg); err != nil {
+		p.Log().Warn("player send msg error", "msgID", msgId, "err", err)
+		return
+	}
+}
+
+// 获取玩家当前的链接，断线等待重连时为nil
+func (p *Player) GetConn() ziface.IConnection {
+	p.connLock.RLock()
+	defer p.connLock.RUnlock()
+	return p.Conn
+}
 
+// 绑定玩家的链接，断线时设置为nil，重连时设置为新的链接
+func (p *Player) SetConn(conn ziface.IConnection) {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+	p.Conn = conn
+}
+
+// 在玩家的消息Worker中执行f，不会和玩家的消息处理同时修改玩家数据
+// 登录、断线、踢下线等不是由玩家的消息触发的修改使用，服务器没有启动时直接执行
+func RunInPlayerWorker(pid int32, f func()) {
+	if s := utils.Global().TcpServer; s != nil {
+		s.GetMsgHandler().RunInWorker(uint32(pid), f)
+		return
 	}
-	//将二进制文件通过zinx框架SendMsg将数据发送给客户端
-	if p.Conn == nil {
-		fmt.Printf("connection in player %d is nil", p.UserId)
+	f()
+}
+
+// 通知客户端被踢下线的原因之后关闭链接，玩家解除和链接的绑定，不会触发断线重连
+func (p *Player) Kick(kick *pb.Kick) {
+	p.connLock.Lock()
+	conn := p.Conn
+	p.Conn = nil
+	p.connLock.Unlock()
+	if conn == nil {
 		return
 	}
-	if err := p.Conn.SendMsg(msgId, msg); err != nil {
-		fmt.Println("player send msg err")
+	data, err := proto.Marshal(kick)
+	if err != nil {
+		p.Log().Error("marshal error", "msgID", 10, "err", err)
+		conn.Stop()
 		return
 	}
-	return
+	if err := conn.SendAndStop(10, data); err != nil {
+		p.Log().Debug("send kick msg error", "err", err)
+	}
+}
 
+// 带有玩家pid的日志，业务失败时使用
+func (p *Player) Log() *zlog.Logger {
+	return zlog.With("pid", p.UserId)
 }
 
 // 告知客户端玩家pid，同步已经生成的玩家id给客户端
@@ -235,6 +292,16 @@ func (p *Player) SynvSurrounding() {
 	}
 
 	//3 将周围的全部玩家的位置消息发送给当前的玩家MsgID：202 客户端（让自己看到其他玩家）
+	p.sendSyncPlayers(players)
+}
+
+// 断线重连之后只给自己同步周边玩家，周边玩家看到的自己没有变化，不需要广播
+func (p *Player) SyncSurroundingToSelf() {
+	p.sendSyncPlayers(p.GetSurrundingPlayers())
+}
+
+// 给自己发送周边玩家的位置MsgID：202
+func (p *Player) sendSyncPlayers(players []*Player) {
 	//3.1 组建MsgID：202 proto数据
 	//3.1.1制作一个pb.player slice
 	players_proto_msg := make([]*pb.Player, 0, len(players))
@@ -302,9 +369,10 @@ func (p *Player) UpdatePos(x float32, y float32, z float32, v float32) {
 	players := p.GetSurrundingPlayers()
 
 	//依次给每个玩家对应的客户端发送当前玩家位置更新的消息
-	//向周边的每个玩家发送MsgID:200消息，移动位置更新消息
+	//向周边的每个玩家发送MsgID:200消息，移动位置更新消息，后面的位置会覆盖前面的，可以丢弃
+	//客户端开启了UDP通道时通过UDP发送，不会阻塞TCP上的聊天和背包等消息
 	for _, player := range players {
-		player.SendMsg(200, proto_msg)
+		player.SendUnreliableMsg(200, proto_msg)
 	}
 
 }
@@ -339,6 +407,9 @@ func (p *Player) Offline() {
 	//4 世界管理器将当前玩家从AOI中摘除
 	WorldMgrObj.AoiMgr.RemoveFromGridByPos(int(p.UserId), p.X, p.Z) //从格子中删除
 	WorldMgrObj.RemovePlayerByid(p.UserId)
+
+	//5 保存玩家数据
+	p.SaveData()
 }
 
 func (p *Player) OnExchangeAoiGrID(oldGID int, newGID int) error {
@@ -589,7 +660,9 @@ func (self *Player) HandleBagSetIconSet() {
 	fmt.Println("请输入头像id:")
 	var icon int
 	fmt.Scan(&icon)
-	self.RecvSetIcon(icon)
+	if err := self.RecvSetIcon(icon); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (self *Player) HandleBagSetCard() {
@@ -622,7 +695,9 @@ func (self *Player) HandleBagSetCardSet() {
 	fmt.Println("请输入名片id:")
 	var card int
 	fmt.Scan(&card)
-	self.RecvSetCard(card)
+	if err := self.RecvSetCard(card); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (self *Player) HandleBagSetBirth() {
@@ -635,7 +710,9 @@ func (self *Player) HandleBagSetBirth() {
 	fmt.Scan(&month)
 	fmt.Println("请输入日:")
 	fmt.Scan(&day)
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetBirth(month*100 + day)
+	if err := self.SetBirth(month*100 + day); err != nil {
+		fmt.Println(err)
+	}
 }
 
 // 背包
@@ -711,7 +788,9 @@ func (self *Player) HandleBagAddItem() {
 	fmt.Scan(&itemId)
 	fmt.Println("物品数量")
 	fmt.Scan(&itemNum)
-	self.GetModBag().AddItem(itemId, int64(itemNum))
+	if err := self.GetModBag().AddItem(itemId, int64(itemNum)); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (self *Player) HandleBagRemoveItem() {
@@ -721,7 +800,9 @@ func (self *Player) HandleBagRemoveItem() {
 	fmt.Scan(&itemId)
 	fmt.Println("物品数量")
 	fmt.Scan(&itemNum)
-	self.GetModBag().RemoveItemToBag(itemId, int64(itemNum))
+	if err := self.GetModBag().RemoveItemToBag(itemId, int64(itemNum)); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (self *Player) HandleBagUseItem() {
@@ -731,7 +812,9 @@ func (self *Player) HandleBagUseItem() {
 	fmt.Scan(&itemId)
 	fmt.Println("物品数量")
 	fmt.Scan(&itemNum)
-	self.GetModBag().UseItem(itemId, int64(itemNum))
+	if err := self.GetModBag().UseItem(itemId, int64(itemNum)); err != nil {
+		fmt.Println(err)
+	}
 }
 
 func (self *Player) HandleBagWindStatue() {
@@ -1032,12 +1115,12 @@ func (self *Player) HandleTakeOffWeapon() {
 		RoleInfo.ShowInfo(self)
 	}
 } //对外接口
-func (self *Player) RecvSetIcon(iconId int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetIcon(iconId)
+func (self *Player) RecvSetIcon(iconId int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetIcon(iconId)
 }
 
-func (self *Player) RecvSetCard(cardId int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetCard(cardId)
+func (self *Player) RecvSetCard(cardId int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetCard(cardId)
 }
 
 func (self *Player) RecvSetName(name string) {
@@ -1048,28 +1131,28 @@ func (self *Player) RecvSetSign(sign string) {
 	self.GetMod(MOD_PLAYER).(*ModPlayer).SetSign(sign)
 }
 
-func (self *Player) ReduceWorldLevel() {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).ReduceWorldLevel()
+func (self *Player) ReduceWorldLevel() error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).ReduceWorldLevel()
 }
 
-func (self *Player) ReturnWorldLevel() {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).ReturnWorldLevel()
+func (self *Player) ReturnWorldLevel() error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).ReturnWorldLevel()
 }
 
-func (self *Player) SetBirth(birth int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetBirth(birth)
+func (self *Player) SetBirth(birth int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetBirth(birth)
 }
 
-func (self *Player) SetShowCard(showCard []int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetShowCard(showCard, self)
+func (self *Player) SetShowCard(showCard []int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetShowCard(showCard, self)
 }
 
-func (self *Player) SetShowTeam(showRole []int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetShowTeam(showRole, self)
+func (self *Player) SetShowTeam(showRole []int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetShowTeam(showRole, self)
 }
 
-func (self *Player) SetHideShowTeam(isHide int) {
-	self.GetMod(MOD_PLAYER).(*ModPlayer).SetHideShowTeam(isHide, self)
+func (self *Player) SetHideShowTeam(isHide int) error {
+	return self.GetMod(MOD_PLAYER).(*ModPlayer).SetHideShowTeam(isHide, self)
 }
 
 func (self *Player) SetEventState(state int) {
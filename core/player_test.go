@@ -0,0 +1,60 @@
+package core
+
+import (
+	"server-1.1.0/network/utils"
+	"sync"
+	"testing"
+)
+
+// 测试时把存档目录指向临时目录，测试结束时恢复配置
+func setTestSavePath(t *testing.T) string {
+	dir := t.TempDir()
+	old := utils.Global()
+	g := *old
+	g.LocalSavePath = dir
+	utils.SetGlobal(&g)
+	t.Cleanup(func() { utils.SetGlobal(old) })
+	return dir
+}
+
+// 玩家下线时从世界中移除并保存所有模块的数据，再次创建时加载存档
+func TestPlayerOfflineSavesData(t *testing.T) {
+	setTestSavePath(t)
+	p := NewPlayer(nil, 1)
+	WorldMgrObj.AddPlayer(p)
+	p.GetModPlayer().SetName("存档测试")
+	p.Offline()
+
+	if WorldMgrObj.GetPlayerByPid(1) != nil {
+		t.Error("player should be removed from world after offline")
+	}
+	if name := NewPlayer(nil, 1).GetModPlayer().Name; name != "存档测试" {
+		t.Errorf("name = %s after reload, want 存档测试", name)
+	}
+}
+
+// 不同玩家同时登录时各自创建自己的玩家，不会共用同一个对象
+func TestNewPlayerConcurrent(t *testing.T) {
+	setTestSavePath(t)
+	const n = 50
+	players := make([]*Player, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			players[i] = NewPlayer(nil, int32(i+1))
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[*Player]bool)
+	for i, p := range players {
+		if p.UserId != int32(i+1) {
+			t.Errorf("player %d UserId = %d", i+1, p.UserId)
+		}
+		if seen[p] {
+			t.Errorf("player %d shares the same object with another player", i+1)
+		}
+		seen[p] = true
+	}
+}
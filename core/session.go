@@ -0,0 +1,166 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/pb/pb"
+	"sync"
+	"time"
+)
+
+// 登录成功之后的会话，断线之后在保留时间内可以使用token重连到同一个玩家
+type Session struct {
+	Token   string
+	Pid     int32
+	Account string
+	//断线之后等待重连的计时器，在线时为nil
+	expire *time.Timer
+}
+
+// 会话管理，每个玩家同时只有一个有效的token
+// 修改玩家的方法(Disconnect、Resume、ResumePid、KickOffline)需要在玩家的Worker中调用(RunInPlayerWorker)
+type SessionManager struct {
+	lock     sync.Mutex
+	sessions map[string]*Session //token -> 会话
+	pids     map[int32]*Session  //pid -> 会话
+}
+
+// 提供一个对外会话管理模块句柄（全局）
+var SessionMgrObj = &SessionManager{
+	sessions: make(map[string]*Session),
+	pids:     make(map[int32]*Session),
+}
+
+// 登录成功时为玩家创建新的会话，之前的token失效
+func (sm *SessionManager) Create(pid int32, account string) (*Session, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	session := &Session{Token: hex.EncodeToString(buf), Pid: pid, Account: account}
+
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	sm.removeLocked(pid)
+	sm.sessions[session.Token] = session
+	sm.pids[pid] = session
+	return session, nil
+}
+
+// token对应的玩家ID，用于找到处理重连的Worker
+func (sm *SessionManager) Pid(token string) (int32, bool) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	session := sm.sessions[token]
+	if session == nil {
+		return 0, false
+	}
+	return session.Pid, true
+}
+
+// 玩家的链接断开，grace大于0时解除和链接的绑定并保存数据，grace时间内没有重连时调用Offline
+// grace为0或者没有会话时立即下线，返回是否在等待重连
+// 玩家已经绑定了其他链接(例如已经重连或者在其他地方登录)时不做处理
+func (sm *SessionManager) Disconnect(player *Player, conn ziface.IConnection, grace time.Duration) bool {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	if player.GetConn() != conn {
+		return false
+	}
+	session := sm.pids[player.UserId]
+	if session == nil || grace <= 0 {
+		sm.removeLocked(player.UserId)
+		player.SetConn(nil)
+		player.Offline()
+		return false
+	}
+	player.SetConn(nil)
+	player.SaveData()
+	session.expire = time.AfterFunc(grace, func() {
+		RunInPlayerWorker(player.UserId, func() {
+			sm.lock.Lock()
+			//已经重连或者创建了新的会话
+			if sm.pids[player.UserId] != session || session.expire == nil {
+				sm.lock.Unlock()
+				return
+			}
+			sm.removeLocked(player.UserId)
+			sm.lock.Unlock()
+			player.Log().Info("reconnect grace expired, player offline")
+			player.Offline()
+		})
+	})
+	return true
+}
+
+// 使用token重连，玩家绑定新的链接，位置和模块数据不变
+// token不存在或者已经过期时返回SessionExpired
+func (sm *SessionManager) Resume(token string, conn ziface.IConnection) (*Player, *Session, error) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	return sm.resumeLocked(sm.sessions[token], conn)
+}
+
+// 使用账号密码登录时，玩家还在断线等待中或者在其他地方在线时接管玩家
+func (sm *SessionManager) ResumePid(pid int32, conn ziface.IConnection) (*Player, *Session, error) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	return sm.resumeLocked(sm.pids[pid], conn)
+}
+
+// 玩家绑定新的链接，玩家还在线时先通知旧的链接在其他地方登录，保存数据之后再绑定
+func (sm *SessionManager) resumeLocked(session *Session, conn ziface.IConnection) (*Player, *Session, error) {
+	if session == nil {
+		return nil, nil, NewGameError(pb.ErrorCode_SessionExpired, "会话已过期，请重新登录")
+	}
+	//计时器已经触发，玩家正在下线
+	if session.expire != nil && !session.expire.Stop() {
+		return nil, nil, NewGameError(pb.ErrorCode_SessionExpired, "会话已过期，请重新登录")
+	}
+	player := WorldMgrObj.GetPlayerByPid(session.Pid)
+	if player == nil {
+		sm.removeLocked(session.Pid)
+		return nil, nil, NewGameError(pb.ErrorCode_SessionExpired, "会话已过期，请重新登录")
+	}
+	if session.expire == nil {
+		player.Log().Info("player logged in elsewhere, kick old connection", "connID", conn.GetConnId())
+		player.Kick(&pb.Kick{Reason: pb.KickReason_LoggedInElsewhere, Msg: "账号在其他地方登录"})
+		player.SaveData()
+	}
+	session.expire = nil
+	player.SetConn(conn)
+	return player, session, nil
+}
+
+// 踢掉在线或者等待重连的玩家并立即下线，会话失效，不能再使用token重连
+// 玩家已经在下线时不做处理，其他goroutine中通过RunInPlayerWorker调用
+func (sm *SessionManager) KickOffline(player *Player, kick *pb.Kick) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	if sm.pids[player.UserId] == nil && player.GetConn() == nil {
+		return
+	}
+	sm.removeLocked(player.UserId)
+	player.Kick(kick)
+	player.Offline()
+}
+
+// 移除玩家的会话，玩家正常下线时调用
+func (sm *SessionManager) Remove(pid int32) {
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+	sm.removeLocked(pid)
+}
+
+func (sm *SessionManager) removeLocked(pid int32) {
+	session := sm.pids[pid]
+	if session == nil {
+		return
+	}
+	if session.expire != nil {
+		session.expire.Stop()
+	}
+	delete(sm.pids, pid)
+	delete(sm.sessions, session.Token)
+}
@@ -1,6 +1,10 @@
 package core
 
-import "sync"
+import (
+	"server-1.1.0/network/zmetrics"
+	"strconv"
+	"sync"
+)
 
 // 当前游戏的世界总管理模块
 type WorldManager struct {
@@ -19,6 +23,24 @@ func init() {
 		AoiMgr:  NewAOIManager(AOI_MIN_X, AOI_MAX_X, AOI_CNTS_X, AOI_MIN_Y, AOI_MAX_Y, AOI_CNTS_Y),
 		Players: make(map[int32]*Player),
 	}
+	WorldMgrObj.registerMetrics()
+}
+
+// 注册在线玩家和AOI格子的指标
+func (wm *WorldManager) registerMetrics() {
+	zmetrics.NewGaugeFunc("game_online_players", "Players currently in the world.", func() float64 {
+		wm.pLock.RLock()
+		defer wm.pLock.RUnlock()
+		return float64(len(wm.Players))
+	})
+	zmetrics.NewGaugeVecFunc("game_aoi_grid_players", "Players in each AOI grid, empty grids are omitted.", []string{"grid"},
+		func(emit func(value float64, labelValues ...string)) {
+			for gID := 0; gID < len(wm.AoiMgr.grids); gID++ {
+				if n := wm.AoiMgr.grids[gID].PlayerCount(); n > 0 {
+					emit(float64(n), strconv.Itoa(gID))
+				}
+			}
+		})
 }
 
 // 提供添加一个玩家的的功能，将玩家添加进玩家信息表Players
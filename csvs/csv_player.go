@@ -26,3 +26,14 @@ func GetNowLevelConfig(level int) *ConfigPlayerLevel {
 	}
 	return ConfigPlayerLevelSlice[level-1]
 }
+
+// 等级配置中最大的世界等级
+func GetMaxWorldLevel() int {
+	max := 0
+	for _, config := range ConfigPlayerLevelSlice {
+		if config.WorldLevel > max {
+			max = config.WorldLevel
+		}
+	}
+	return max
+}
@@ -4,7 +4,7 @@ go 1.20
 
 require (
 	github.com/aceld/zinx v1.1.21
+	github.com/gorilla/websocket v1.5.0
+	golang.org/x/crypto v0.17.0
 	google.golang.org/protobuf v1.26.0
 )
-
-require github.com/gorilla/websocket v1.5.0 // indirect
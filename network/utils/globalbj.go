@@ -1,9 +1,10 @@
 package utils
 
 import (
-	"encoding/json"
 	"os"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"sync/atomic"
 )
 
 /*
@@ -14,52 +15,189 @@ type DBConfig struct {
 	DBUser     string `json:"dbuser" `
 	DBPassword string `json:"dbpassword" `
 }
+
+// 令牌桶限流配置
+type RateLimitConfig struct {
+	Rate          float64 //每秒补充的令牌数，0表示不限流
+	Burst         int     //令牌桶容量，允许的瞬间突发数量
+	Action        string  //超过限流之后的处理 drop warn disconnect
+	MaxViolations int     //Action为disconnect时，1分钟内违规多少次之后断开链接
+}
+
 type GlobalObj struct {
 	//server
 	TcpServer ziface.IServer //当前Zinx的全局Server对象
 	Host      string         //当前服务器主机IP
 	TcpPort   int            //当前服务器主机监听端口号
 	Name      string         //当前服务器名称
+	WsPort    int            //WebSocket监听端口号，0表示不开启
+	WsPath    string         //WebSocket升级请求的路径
 	//zinx
 	Version          string //当前Zinx版本号
 	MaxPacketSize    uint32 //都需数据包的最大值
 	MaxConn          int    //当前服务器主机允许的最大链接个数
 	WorkerPoolSize   uint32 //当前业务工作Worker池的Goroutine数量
 	MaxWorkerTaskLen uint32
+	PacketCodec      string    //封包格式 default(小端) bigendian varint flag
+	ShutdownTimeout  int       //优雅关闭的最长等待时间(秒)，超时强制退出
 	LocalSavePath    string    `json:"localsavepath"` //! 本地存储路径
 	DBConfig         *DBConfig `json:"database" `
+
+	//udp 移动等可以丢弃的消息使用，链接建立之后通过TCP下发token
+	UdpPort   int      //UDP监听端口号，0表示不开启
+	UdpMsgIDs []uint32 //可以通过UDP发送给服务器的msgID，其他msgID的数据报直接丢弃
+
+	//compress 需要使用带标志位的封包格式(flag)
+	CompressThreshold uint32 //发送的数据超过该长度时进行压缩，0表示不压缩
+	MaxDecompressSize uint32 //收到的压缩数据解压之后的最大长度
+
+	//tls 同时配置证书和私钥时使用TLS监听
+	CertFile     string //服务器证书路径
+	KeyFile      string //服务器私钥路径
+	ClientCAFile string //校验客户端证书的CA路径(内部工具使用)，客户端不提供证书时不校验
+
+	//send buffer
+	MaxMsgChanLen      uint32 //每个链接带缓冲发送队列的长度
+	SendOverflowPolicy string //SendBuffMsg队列满时的策略 disconnect drop-oldest block
+
+	//worker pool
+	TaskQueueFullPolicy string //Worker消息队列满时的策略 block reject spill
+
+	//rate limit
+	ConnRateLimit *RateLimitConfig            //每个链接所有消息合计的限流
+	MsgRateLimits map[uint32]*RateLimitConfig //每个链接按msgID的限流
+
+	//metrics
+	MetricsPort int    //Prometheus指标的http监听端口号，0表示不开启
+	MetricsPath string //指标的http路径
+
+	//capture 抓包文件可以用cmd/replay回放
+	CaptureDir string //抓包文件保存目录
+	CaptureAll bool   //所有链接都自动抓包，调试时使用
+
+	//log
+	LogLevel      string //日志级别 debug info warn error
+	LogFormat     string //日志格式 text json
+	LogFile       string //日志文件路径，为空时输出到标准输出
+	LogMaxSize    int    //单个日志文件的最大大小(MB)，超过之后切分
+	LogMaxBackups int    //保留的旧日志文件数量
+
+	//admission
+	Maintenance bool //维护模式，拒绝所有新的链接
+
+	//ban word 热更新之后立即生效
+	BanWords []string //违禁词，支持正则表达式
+	//admin 只监听127.0.0.1，需要在本机访问
+	AdminPort int    //管理接口的http监听端口号，0表示不开启
+	AdminPath string //管理接口的http路径前缀(例如/admin)
+	//handshake 链接建立之后客户端必须先发送握手消息
+	MinProtocolVersion uint32 //支持的最小客户端协议版本号
+	MaxProtocolVersion uint32 //支持的最大客户端协议版本号，0表示不限制
+	HandshakeTimeout   int    //链接建立之后等待握手的最长时间(秒)，超时断开链接，0表示不限制
+	//reconnect
+	ReconnectGrace int //断线之后保留玩家的时间(秒)，期间可以使用会话token重连，0表示断线立即下线
+	//gm 登录时设置ModPlayer.IsGM，热更新之后下次登录生效
+	GMAccounts []string //GM账号，可以在世界聊天中使用/开头的GM命令
+	//ip filter 地址可以是CIDR或者单独的IP
+	IPAllowList  []string //不为空时只允许名单中的地址链接
+	IPDenyList   []string //禁止链接的地址，优先于IPAllowList
+	MaxConnPerIP int      //每个IP同时存在的最大链接数，0表示不限制
+
+	//heartbeat
+	HeartbeatInterval int //心跳检测间隔(秒)，检测时链接空闲超过一半的间隔服务器主动发送心跳
+	MaxIdleTime       int //链接最长空闲时间(秒)，超过之后断开链接，0表示不检测
 }
 
 /*
 定义一个全局的对象
 */
-var GlobalObject *GlobalObj
+// 运行时热更新时整体替换，读取使用Global()
+var globalObject atomic.Pointer[GlobalObj]
+
+// 获取当前的全局配置，返回的配置是只读的，多个goroutine可以同时读取
+func Global() *GlobalObj {
+	return globalObject.Load()
+}
+
+// 替换全局配置，需要修改时复制一份修改之后再替换，不能直接修改Global()返回的配置
+func SetGlobal(g *GlobalObj) {
+	globalObject.Store(g)
+}
+
+// 配置文件路径，测试时可以修改
+var ConfigFile = "./conf/zinx.json"
 
+// 启动时加载配置文件，配置文件格式错误或者校验失败时panic
+// 运行时重新加载使用ReloadConfig，失败时保留当前的配置
 func (g *GlobalObj) Reload() {
-	data, err := os.ReadFile("./conf/zinx.json")
+	loaded, err := LoadConfig(ConfigFile)
 	if err != nil {
 		panic(err)
 	}
-	//将json解析到struct中
-	err = json.Unmarshal(data, &GlobalObject)
-	if err != nil {
+	loaded.TcpServer = g.TcpServer
+	*g = *loaded
+}
+
+// 提供一个init方法，初始化全局配置
+func init() {
+	g := newDefaultGlobalObj()
+	//从conf/zinx.json 配置文件中加载一些用户配置的参数，没有配置文件时使用默认值
+	if _, err := os.Stat(ConfigFile); err == nil {
+		g.Reload()
+	}
+	SetGlobal(g)
+	if err := g.SetupLog(); err != nil {
 		panic(err)
 	}
 }
 
-// 提供一个init方法，初始化当前对象GlobalObject
-func init() {
-	//如果配置文件没有加载，默认值
-	GlobalObject = &GlobalObj{
+// 默认配置，配置文件中没有的字段使用默认值
+func newDefaultGlobalObj() *GlobalObj {
+	return &GlobalObj{
 		Name:             "ZinxServerApp",
 		Version:          "v1.0",
 		TcpPort:          8999,
 		Host:             "0.0.0.0",
+		WsPath:           "/",
 		MaxConn:          1000,
 		MaxPacketSize:    4096,
 		WorkerPoolSize:   10,
 		MaxWorkerTaskLen: 1024, //每个worker对应的消息队列的任务最大值
+		ShutdownTimeout:  10,
+
+		CompressThreshold: 0,
+		MaxDecompressSize: 1024 * 1024,
+
+		MaxMsgChanLen:      1024,
+		SendOverflowPolicy: "disconnect",
+
+		TaskQueueFullPolicy: "block",
+
+		MetricsPath: "/metrics",
+
+		LogLevel:      "info",
+		LogFormat:     "text",
+		LogMaxSize:    100,
+		LogMaxBackups: 5,
+
+		MinProtocolVersion: 1,
+		MaxProtocolVersion: 0,
+		HandshakeTimeout:   10,
+
+		ReconnectGrace: 0,
+
+		HeartbeatInterval: 10,
+		MaxIdleTime:       0,
 	}
-	//从conf/zinx.txt 配置文件中加载一些用户配置的参数
-	GlobalObject.Reload()
+}
+
+// 按照配置设置日志
+func (g *GlobalObj) SetupLog() error {
+	return zlog.Setup(zlog.Config{
+		Level:      g.LogLevel,
+		Format:     g.LogFormat,
+		File:       g.LogFile,
+		MaxSize:    g.LogMaxSize,
+		MaxBackups: g.LogMaxBackups,
+	})
 }
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"regexp"
+	"server-1.1.0/network/zlog"
+	"strings"
+	"sync"
+)
+
+// 运行时可以直接修改的字段，其他字段修改之后需要重启服务器才能生效
+var hotReloadFields = map[string]bool{
+	"MaxConn":            true,
+	"MaxPacketSize":      true,
+	"ConnRateLimit":      true,
+	"MsgRateLimits":      true,
+	"LogLevel":           true,
+	"BanWords":           true,
+	"Maintenance":        true,
+	"IPAllowList":        true,
+	"IPDenyList":         true,
+	"MaxConnPerIP":       true,
+	"MinProtocolVersion": true,
+	"MaxProtocolVersion": true,
+	"HandshakeTimeout":   true,
+	"ReconnectGrace":     true,
+	"GMAccounts":         true,
+	"CaptureAll":         true,
+}
+
+// 保证同一时间只有一个重新加载
+var reloadLock sync.Mutex
+
+// 读取并校验配置文件，配置文件中没有的字段使用默认值
+func LoadConfig(path string) (*GlobalObj, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g := newDefaultGlobalObj()
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, fmt.Errorf("validate %s: %w", path, err)
+	}
+	return g, nil
+}
+
+// 校验配置，只检查运行时会出错或者没有意义的值
+func (g *GlobalObj) Validate() error {
+	if g.MaxConn < 0 {
+		return fmt.Errorf("MaxConn %d should not be negative", g.MaxConn)
+	}
+	if g.MaxPacketSize == 0 {
+		return fmt.Errorf("MaxPacketSize should be greater than 0")
+	}
+	if _, err := zlog.ParseLevel(g.LogLevel); err != nil {
+		return fmt.Errorf("LogLevel: %w", err)
+	}
+	if g.LogFormat != "" && g.LogFormat != zlog.FormatText && g.LogFormat != zlog.FormatJSON {
+		return fmt.Errorf("unknown LogFormat %q", g.LogFormat)
+	}
+	switch g.PacketCodec {
+	case "", "default", "littleendian", "bigendian", "varint":
+		//不带标志位的封包格式无法标记压缩，避免配置了压缩却静默不生效
+		if g.CompressThreshold > 0 {
+			return fmt.Errorf("CompressThreshold %d requires PacketCodec flag, got %q", g.CompressThreshold, g.PacketCodec)
+		}
+	case "flag":
+	default:
+		return fmt.Errorf("unknown PacketCodec %q", g.PacketCodec)
+	}
+	if err := validateRateLimit(g.ConnRateLimit); err != nil {
+		return fmt.Errorf("ConnRateLimit: %w", err)
+	}
+	for msgID, config := range g.MsgRateLimits {
+		if err := validateRateLimit(config); err != nil {
+			return fmt.Errorf("MsgRateLimits[%d]: %w", msgID, err)
+		}
+	}
+	for _, list := range [][]string{g.IPAllowList, g.IPDenyList} {
+		if err := validateIPList(list); err != nil {
+			return err
+		}
+	}
+	if g.MaxConnPerIP < 0 {
+		return fmt.Errorf("MaxConnPerIP %d should not be negative", g.MaxConnPerIP)
+	}
+	if g.MaxProtocolVersion > 0 && g.MaxProtocolVersion < g.MinProtocolVersion {
+		return fmt.Errorf("MaxProtocolVersion %d is less than MinProtocolVersion %d", g.MaxProtocolVersion, g.MinProtocolVersion)
+	}
+	for _, word := range g.BanWords {
+		if _, err := regexp.Compile(word); err != nil {
+			return fmt.Errorf("BanWords %q: %w", word, err)
+		}
+	}
+	return nil
+}
+
+func validateRateLimit(config *RateLimitConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Rate < 0 || config.Burst < 0 || config.MaxViolations < 0 {
+		return fmt.Errorf("Rate, Burst and MaxViolations should not be negative")
+	}
+	switch config.Action {
+	case "", "drop", "warn", "disconnect":
+		return nil
+	}
+	return fmt.Errorf("unknown Action %q", config.Action)
+}
+
+// 地址可以是CIDR或者单独的IP
+func validateIPList(list []string) error {
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if strings.Contains(s, "/") {
+			if _, _, err := net.ParseCIDR(s); err != nil {
+				return err
+			}
+		} else if net.ParseIP(s) == nil {
+			return fmt.Errorf("invalid ip %q", s)
+		}
+	}
+	return nil
+}
+
+// 运行时重新加载配置文件，返回已经生效的字段和修改之后需要重启才能生效的字段
+// 配置文件格式错误或者校验失败时返回错误，当前的配置不变
+// 生效的字段复制到新的配置对象之后原子替换全局配置，不会读到改了一半的配置
+func ReloadConfig() (applied, restart []string, err error) {
+	return reloadFrom(ConfigFile)
+}
+
+func reloadFrom(path string) (applied, restart []string, err error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	old := Global()
+	next := *old
+	nextValue := reflect.ValueOf(&next).Elem()
+	oldValue := reflect.ValueOf(old).Elem()
+	loadedValue := reflect.ValueOf(loaded).Elem()
+	for i := 0; i < oldValue.NumField(); i++ {
+		name := oldValue.Type().Field(i).Name
+		if name == "TcpServer" {
+			continue
+		}
+		if reflect.DeepEqual(oldValue.Field(i).Interface(), loadedValue.Field(i).Interface()) {
+			continue
+		}
+		if !hotReloadFields[name] {
+			restart = append(restart, name)
+			continue
+		}
+		nextValue.Field(i).Set(loadedValue.Field(i))
+		applied = append(applied, name)
+	}
+	if len(applied) == 0 {
+		return applied, restart, nil
+	}
+
+	if next.LogLevel != old.LogLevel {
+		level, _ := zlog.ParseLevel(next.LogLevel)
+		zlog.SetLevel(level)
+	}
+	SetGlobal(&next)
+	return applied, restart, nil
+}
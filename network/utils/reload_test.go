@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 只替换可以热更新的字段，其他字段报告需要重启，格式错误时保留当前配置
+func TestReloadConfig(t *testing.T) {
+	old := Global()
+	t.Cleanup(func() { SetGlobal(old) })
+	SetGlobal(newDefaultGlobalObj())
+	running := Global()
+
+	path := filepath.Join(t.TempDir(), "zinx.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"MaxConn": 10, "TcpPort": 7000, "BanWords": ["外挂"]}`)
+	applied, restart, err := reloadFrom(path)
+	if err != nil {
+		t.Fatal("reload err:", err)
+	}
+	if !reflect.DeepEqual(applied, []string{"MaxConn", "BanWords"}) {
+		t.Errorf("applied = %v", applied)
+	}
+	if !reflect.DeepEqual(restart, []string{"TcpPort"}) {
+		t.Errorf("restart = %v", restart)
+	}
+	if g := Global(); g.MaxConn != 10 || g.TcpPort != running.TcpPort {
+		t.Errorf("MaxConn = %d, TcpPort = %d", g.MaxConn, g.TcpPort)
+	}
+	if running.MaxConn == 10 {
+		t.Error("running config should be replaced, not modified")
+	}
+
+	current := Global()
+	for _, content := range []string{
+		`{"MaxConn": 20`,
+		`{"MaxConn": 20, "LogLevel": "verbose"}`,
+		`{"MaxConn": 20, "IPDenyList": ["10.0.0.0/33"]}`,
+		`{"MaxConn": 20, "PacketCodec": "default", "CompressThreshold": 1024}`,
+		`{"MaxConn": 20, "PacketCodec": "gzip"}`,
+		`{"MaxConn": 20, "MinProtocolVersion": 3, "MaxProtocolVersion": 2}`,
+		`{"MaxConn": 20, "MsgRateLimits": {"2": {"Rate": 1, "Action": "kick"}}}`,
+	} {
+		write(content)
+		if _, _, err := reloadFrom(path); err == nil {
+			t.Errorf("reload %s should fail", content)
+		}
+		if g := Global(); g != current || g.MaxConn != 10 {
+			t.Errorf("reload %s should keep running config", content)
+		}
+	}
+}
+
+// 仓库中的配置文件可以通过校验，开启压缩时使用带标志位的封包格式
+func TestShippedConfig(t *testing.T) {
+	g, err := LoadConfig("../../conf/zinx.json")
+	if err != nil {
+		t.Fatal("load conf/zinx.json err:", err)
+	}
+	if g.CompressThreshold > 0 && g.PacketCodec != "flag" {
+		t.Errorf("PacketCodec = %q with CompressThreshold %d", g.PacketCodec, g.CompressThreshold)
+	}
+}
@@ -6,14 +6,40 @@ import "net"
 type IConnection interface {
 	Start()
 	Stop()
-	//获取当前链接的绑定socket conn
-	GetTcpConnection() *net.TCPConn
+	//获取当前链接的绑定socket conn(TCP或WebSocket)
+	GetConnection() net.Conn
 	//获取当前链接模块的链接ID
 	GetConnId() uint32
 	//获取远程客户端的 tcp状态 Ip port
 	RemoteAddr() net.Addr
-	//发送数据，将数据发送给远程客户端
+	//发送数据，将数据发送给远程客户端，阻塞到数据交给Writer为止
 	SendMsg(msgId uint32, data []byte) error
+	//带缓冲发送数据，发送队列满时按照配置的策略处理
+	SendBuffMsg(msgId uint32, data []byte) error
+	//发送可以丢弃的数据，发送队列满时丢弃最旧的数据
+	SendDroppableMsg(msgId uint32, data []byte) error
+	//发送最后一个消息之后关闭链接，例如拒绝客户端或者踢下线，不阻塞
+	SendAndStop(msgId uint32, data []byte) error
+	//开启UDP通道，通过TCP把token发送给客户端，服务器没有开启UDP时返回错误
+	StartUnreliable() error
+	//通过UDP发送可以丢弃的数据(例如移动)，UDP通道不可用时同SendDroppableMsg
+	SendUnreliableMsg(msgId uint32, data []byte) error
+	//带缓冲发送请求的回复，seq为对应请求的序号
+	SendSeqMsg(seq uint32, msgId uint32, data []byte) error
+	//设置分配Worker使用的key，例如玩家ID，同一个key的消息由同一个Worker按顺序处理
+	SetDispatchKey(key uint32)
+	//获取分配Worker使用的key，没有设置时为链接ID
+	GetDispatchKey() uint32
+	//从客户端收到的字节数
+	BytesReceived() uint64
+	//发送给客户端的字节数
+	BytesSent() uint64
+	//开始把收发的消息记录到抓包文件中，用于离线回放
+	StartCapture(path string) error
+	//停止抓包
+	StopCapture()
+	//当前链接是否已经关闭
+	IsClosed() bool
 	//设置链接属性
 	Setproperty(key string, value interface{})
 	//获取链接属性
@@ -23,4 +49,4 @@ type IConnection interface {
 }
 
 // 定义一个处理链接业务的方法
-type HandleFunc func(*net.TCPConn, []byte, int) error
+type HandleFunc func(net.Conn, []byte, int) error
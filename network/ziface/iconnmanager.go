@@ -10,6 +10,8 @@ type IConnManager interface {
 	Get(connID uint32) (IConnection, error)
 	//得到当前连接总数
 	Len() int
+	//遍历当前所有链接，fn中可以停止链接
+	Range(fn func(conn IConnection))
 	//清除并终止所有链接
 	ClearConn()
 }
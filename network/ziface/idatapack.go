@@ -1,8 +1,10 @@
 package ziface
 
+import "io"
+
 // 封包拆包的模块，直接面向TCP链接中的数据流，用于处理TCP粘包问题
 type IDataPack interface {
-	GetHeadLen() uint32                //获取包头长度方法
-	Pack(msg IMessage) ([]byte, error) //封包方法
-	Unpack([]byte) (IMessage, error)   //拆包方法
+	GetHeadLen() uint32                   //获取包头长度方法，变长包头返回最大长度
+	Pack(msg IMessage) ([]byte, error)    //封包方法
+	Unpack(r io.Reader) (IMessage, error) //拆包方法，从数据流中读出一个完整的消息
 }
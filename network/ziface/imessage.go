@@ -7,8 +7,12 @@ type IMessage interface {
 	GetDataLen() uint32 //获取消息数据段长度
 	GetMsgId() uint32   //获取消息ID
 	GetData() []byte    //获取消息内容
+	GetFlags() uint8    //获取消息标志位
+	GetSeq() uint32     //获取请求序号，没有序号时为0
 
 	SetMsgId(uint32)   //设计消息ID
 	SetData([]byte)    //设计消息内容
 	SetDataLen(uint32) //设置消息数据段长度
+	SetFlags(uint8)    //设置消息标志位
+	SetSeq(uint32)     //设置请求序号
 }
@@ -0,0 +1,5 @@
+package ziface
+
+// 中间件，在Router处理消息之前执行
+// 调用next()继续执行后面的中间件和Router，不调用next()则中断本次消息的处理
+type Middleware func(request IRequest, next func())
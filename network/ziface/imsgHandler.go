@@ -5,10 +5,29 @@ type IMsgHanle interface {
 	//调度、执行对应的Router消息处理方法
 	DoMsgHandler(request IRequest)
 
-	//为消息添加具体的处理逻辑
-	AddRouter(msgID uint32, router IRouter)
+	//为消息添加具体的处理逻辑，middlewares只对这个msgID生效
+	AddRouter(msgID uint32, router IRouter, middlewares ...Middleware)
+	//添加全局中间件，对所有msgID生效，先于路由自己的中间件执行
+	Use(middlewares ...Middleware)
 	//启动工作池
 	StartWorkerPool()
+	//关闭工作池，等待消息队列中剩余的消息处理完毕
+	StopWorkerPool()
 	// 将消息交给TaskQueue,由Worker进行处理
 	SendMsgToTaskQueue(request IRequest)
+	//在key对应的Worker中执行task，和分配key相同的链接的消息不会同时执行，不会阻塞
+	RunInWorker(key uint32, task func())
+	//运行时调整Worker数量，已经收到的消息处理完之后再切换
+	ResizeWorkerPool(size uint32) error
+	//获取工作池的统计数据
+	GetWorkerPoolStats() WorkerPoolStats
+}
+
+// 工作池的统计数据
+type WorkerPoolStats struct {
+	WorkerPoolSize uint32 //当前Worker数量
+	QueueLens      []int  //每个Worker消息队列中等待处理的消息数量
+	OverflowLens   []int  //每个Worker溢出队列中等待处理的消息数量
+	Rejected       uint64 //队列满时被拒绝的消息总数
+	Spilled        uint64 //队列满时放入溢出队列的消息总数
 }
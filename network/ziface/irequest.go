@@ -9,4 +9,13 @@ type IRequest interface {
 	GetData() []byte
 	//得到请求的消息数据ID
 	GetMsgID() uint32
+	//得到请求序号，客户端没有携带序号时为0
+	GetSeq() uint32
+	//回复本次请求，回复的消息带上请求序号
+	Reply(msgID uint32, data []byte) error
+
+	//设置本次请求携带的值，用于中间件向后面的中间件和Router传递数据
+	Set(key string, value interface{})
+	//获取本次请求携带的值
+	Get(key string) (interface{}, bool)
 }
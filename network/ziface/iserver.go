@@ -1,18 +1,54 @@
 package ziface
 
+import (
+	"net"
+	"net/http"
+)
+
+// 链接准入检查，在创建链接之前调用，返回非0的拒绝原因时拒绝链接
+type AdmitHook func(conn net.Conn) uint32
+
+// 抓包时处理消息数据，返回写入抓包文件的数据，用来去掉密码、token等敏感字段
+// dir为"in"或者"out"，不需要处理时原样返回data
+type CaptureRedactor func(dir string, msgID uint32, data []byte) []byte
+
 // 接口层  定义一个服务器接口
 type IServer interface {
-	//启动服务器
-	Start()
+	//启动服务器，监听失败时返回错误
+	Start() error
 	//停止服务器
 	Stop()
-	//运行服务器
-	Serve()
+	//运行服务器，阻塞直到收到退出信号，启动失败时返回错误
+	Serve() error
 
 	//路由功能：给当前的服务注册一个路由方法，供客户端的链接处理使用
-	AddRouter(msgID uint32, router IRouter)
+	//middlewares只对这个msgID生效
+	AddRouter(msgID uint32, router IRouter, middlewares ...Middleware)
+	//添加全局中间件，对所有msgID生效，需要在Start之前调用
+	Use(middlewares ...Middleware)
 	//获取当前的连接管理器
 	GetConnMgr() IConnManager
+	//获取当前的消息管理模块，可以用来调整工作池
+	GetMsgHandler() IMsgHanle
+	//设置当前服务使用的封包拆包模块，需要在Start之前调用
+	SetPacket(packet IDataPack)
+	//获取当前服务使用的封包拆包模块
+	GetPacket() IDataPack
+
+	//添加准入检查，需要在Start之前调用
+	AddAdmitHook(hook AdmitHook)
+	//更新IP黑白名单(CIDR或者IP)和每个IP的链接数限制，可以在运行时调用
+	UpdateIPFilter(allow, deny []string, maxConnPerIP int) error
+	//重新加载zinx.json，返回已经生效的字段和需要重启才能生效的字段，失败时当前的配置不变
+	ReloadConfig() (applied, restart []string, err error)
+	//添加管理接口，路径为AdminPath加上path，只监听127.0.0.1:AdminPort，需要在Start之前调用
+	AddAdminHandler(path string, handler http.HandlerFunc)
+	//设置抓包时的脱敏函数，需要在Start之前调用
+	SetCaptureRedactor(redactor CaptureRedactor)
+	//调用脱敏函数，没有设置时原样返回data
+	RedactCapture(dir string, msgID uint32, data []byte) []byte
+	//判断内容是否包含违禁词，违禁词在zinx.json的BanWords中配置
+	IsBanWord(txt string) bool
 
 	//注册OnConnStart钩子函数的方法
 	SetOnConnStart(func(connection IConnection))
@@ -0,0 +1,70 @@
+package zlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// 日志配置，在zinx.json中配置
+type Config struct {
+	Level      string //日志级别 debug info warn error
+	Format     string //输出格式 text json
+	File       string //日志文件路径，为空时输出到标准输出
+	MaxSize    int    //单个日志文件的最大大小(MB)，超过之后切分，0表示不切分
+	MaxBackups int    //保留的旧日志文件数量
+}
+
+// 按照配置设置日志的级别、格式和输出，可以重复调用
+func Setup(config Config) error {
+	level, err := ParseLevel(config.Level)
+	if err != nil {
+		return err
+	}
+	format := config.Format
+	if format == "" {
+		format = FormatText
+	}
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	var w io.Writer = os.Stdout
+	var file io.Closer
+	if config.File != "" {
+		rw, err := newRotateWriter(config.File, int64(config.MaxSize)*1024*1024, config.MaxBackups)
+		if err != nil {
+			return err
+		}
+		w = rw
+		file = rw
+	}
+
+	std.lock.Lock()
+	oldFile := std.file
+	std.w = w
+	std.file = file
+	std.level = level
+	std.format = format
+	std.lock.Unlock()
+
+	//只关闭之前Setup打开的日志文件
+	if oldFile != nil {
+		oldFile.Close()
+	}
+	return nil
+}
+
+// 设置日志级别
+func SetLevel(level Level) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	std.level = level
+}
+
+// 设置日志输出，测试时使用
+func SetOutput(w io.Writer) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	std.w = w
+}
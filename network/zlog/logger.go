@@ -0,0 +1,221 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志级别
+type Level int8
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARN"
+	case ErrorLevel:
+		return "ERROR"
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// 解析配置中的日志级别 debug info warn error
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return DebugLevel, nil
+	case "", "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q", s)
+}
+
+// 日志输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// 所有Logger共用的输出配置
+type output struct {
+	lock   sync.Mutex
+	w      io.Writer
+	level  Level
+	format string
+	//Setup打开的日志文件，再次Setup时关闭，标准输出和SetOutput设置的输出不会被关闭
+	file io.Closer
+}
+
+var std = &output{w: os.Stdout, level: InfoLevel, format: FormatText}
+
+// 日志记录器，携带固定的key-value字段，例如connID、pid
+type Logger struct {
+	fields []interface{}
+}
+
+// 默认的记录器，没有字段
+var defaultLogger = &Logger{}
+
+// 创建一个带字段的记录器，kv为成对的key value
+func With(kv ...interface{}) *Logger {
+	return defaultLogger.With(kv...)
+}
+
+// 在当前字段的基础上增加字段
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	return &Logger{fields: fields}
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) { l.log(DebugLevel, msg, kv) }
+func (l *Logger) Info(msg string, kv ...interface{})  { l.log(InfoLevel, msg, kv) }
+func (l *Logger) Warn(msg string, kv ...interface{})  { l.log(WarnLevel, msg, kv) }
+func (l *Logger) Error(msg string, kv ...interface{}) { l.log(ErrorLevel, msg, kv) }
+
+func (l *Logger) Debugf(format string, args ...interface{}) { l.logf(DebugLevel, format, args) }
+func (l *Logger) Infof(format string, args ...interface{})  { l.logf(InfoLevel, format, args) }
+func (l *Logger) Warnf(format string, args ...interface{})  { l.logf(WarnLevel, format, args) }
+func (l *Logger) Errorf(format string, args ...interface{}) { l.logf(ErrorLevel, format, args) }
+
+// 默认记录器的方法
+func Debug(msg string, kv ...interface{}) { defaultLogger.log(DebugLevel, msg, kv) }
+func Info(msg string, kv ...interface{})  { defaultLogger.log(InfoLevel, msg, kv) }
+func Warn(msg string, kv ...interface{})  { defaultLogger.log(WarnLevel, msg, kv) }
+func Error(msg string, kv ...interface{}) { defaultLogger.log(ErrorLevel, msg, kv) }
+
+func Debugf(format string, args ...interface{}) { defaultLogger.logf(DebugLevel, format, args) }
+func Infof(format string, args ...interface{})  { defaultLogger.logf(InfoLevel, format, args) }
+func Warnf(format string, args ...interface{})  { defaultLogger.logf(WarnLevel, format, args) }
+func Errorf(format string, args ...interface{}) { defaultLogger.logf(ErrorLevel, format, args) }
+
+// 是否输出该级别的日志，可以在拼接开销较大的日志之前判断
+func Enabled(level Level) bool {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	return level >= std.level
+}
+
+func (l *Logger) logf(level Level, format string, args []interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	l.log(level, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	std.lock.Lock()
+	defer std.lock.Unlock()
+	if level < std.level {
+		return
+	}
+	var buf bytes.Buffer
+	now := time.Now()
+	if std.format == FormatJSON {
+		writeJSON(&buf, now, level, msg, l.fields, kv)
+	} else {
+		writeText(&buf, now, level, msg, l.fields, kv)
+	}
+	std.w.Write(buf.Bytes())
+}
+
+// 文本格式 2006-01-02 15:04:05.000 INFO msg key=value
+func writeText(buf *bytes.Buffer, now time.Time, level Level, msg string, fields, kv []interface{}) {
+	buf.WriteString(now.Format("2006-01-02 15:04:05.000"))
+	buf.WriteByte(' ')
+	buf.WriteString(level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	eachField(fields, kv, func(key string, value interface{}) {
+		buf.WriteByte(' ')
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		s := valueString(value)
+		if s == "" || strings.ContainsAny(s, " \"=\n") {
+			s = strconv.Quote(s)
+		}
+		buf.WriteString(s)
+	})
+	buf.WriteByte('\n')
+}
+
+// json格式，每行一个对象
+func writeJSON(buf *bytes.Buffer, now time.Time, level Level, msg string, fields, kv []interface{}) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, now.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	eachField(fields, kv, func(key string, value interface{}) {
+		buf.WriteByte(',')
+		writeJSONValue(buf, key)
+		buf.WriteByte(':')
+		switch v := value.(type) {
+		case error:
+			writeJSONValue(buf, v.Error())
+		case fmt.Stringer:
+			writeJSONValue(buf, v.String())
+		default:
+			writeJSONValue(buf, v)
+		}
+	})
+	buf.WriteString("}\n")
+}
+
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(data)
+}
+
+// 依次处理固定字段和本次日志的字段，奇数个时最后一个的key为!BADKEY
+func eachField(fields, kv []interface{}, fn func(key string, value interface{})) {
+	for _, list := range [][]interface{}{fields, kv} {
+		for i := 0; i < len(list); i += 2 {
+			if i+1 >= len(list) {
+				fn("!BADKEY", list[i])
+				break
+			}
+			key, ok := list[i].(string)
+			if !ok {
+				key = fmt.Sprint(list[i])
+			}
+			fn(key, list[i+1])
+		}
+	}
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,138 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 切换到测试的输出，结束时恢复默认配置
+func captureOutput(t *testing.T, config Config) *bytes.Buffer {
+	if err := Setup(config); err != nil {
+		t.Fatal("Setup err:", err)
+	}
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	t.Cleanup(func() { Setup(Config{Level: "info"}) })
+	return &buf
+}
+
+// 文本格式按级别过滤，带上固定字段，含有空格的值加引号
+func TestLoggerText(t *testing.T) {
+	buf := captureOutput(t, Config{Level: "info", Format: FormatText})
+
+	logger := With("connID", 1)
+	logger.Debug("debug msg")
+	logger.Info("conn start", "remoteAddr", "127.0.0.1:1234", "err", errors.New("read tcp: eof"))
+
+	out := buf.String()
+	if strings.Contains(out, "debug msg") {
+		t.Error("debug log should be filtered by info level")
+	}
+	if !strings.Contains(out, `INFO conn start connID=1 remoteAddr=127.0.0.1:1234 err="read tcp: eof"`) {
+		t.Errorf("unexpected text output: %q", out)
+	}
+}
+
+// json格式每行一个对象
+func TestLoggerJSON(t *testing.T) {
+	buf := captureOutput(t, Config{Level: "debug", Format: FormatJSON})
+
+	With("pid", 7).Warn("handle request failed", "msgID", 2, "err", errors.New("没有头像"))
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("json output %q err: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "handle request failed" ||
+		record["pid"] != float64(7) || record["msgID"] != float64(2) || record["err"] != "没有头像" {
+		t.Errorf("unexpected json record: %v", record)
+	}
+}
+
+// 超过大小之后切分，只保留maxBackups个旧文件
+func TestRotateWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "zinx.log")
+	w, err := newRotateWriter(path, 10, 2)
+	if err != nil {
+		t.Fatal("newRotateWriter err:", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first111\n", "second22\n", "third333\n", "fourth44\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal("Write err:", err)
+		}
+	}
+
+	want := map[string]string{path: "fourth44\n", path + ".1": "third333\n", path + ".2": "second22\n"}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal("ReadFile err:", err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("backup over maxBackups should be removed")
+	}
+}
+
+// 改名失败时重新以追加方式打开原文件，日志继续写入
+func TestRotateWriterRenameFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zinx.log")
+	//file.1是一个非空目录，改名会失败
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := newRotateWriter(path, 10, 1)
+	if err != nil {
+		t.Fatal("newRotateWriter err:", err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"first111\n", "second22\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal("Write err:", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("ReadFile err:", err)
+	}
+	if string(data) != "first111\nsecond22\n" {
+		t.Errorf("%s = %q, want both lines", path, data)
+	}
+}
+
+// 重新Setup时只关闭之前Setup打开的日志文件，不会关闭标准输出
+func TestSetupCloseOwnFile(t *testing.T) {
+	t.Cleanup(func() { Setup(Config{Level: "info"}) })
+	path := filepath.Join(t.TempDir(), "zinx.log")
+	if err := Setup(Config{Level: "info", File: path}); err != nil {
+		t.Fatal("Setup err:", err)
+	}
+	if _, err := os.Stdout.Stat(); err != nil {
+		t.Fatal("stdout should not be closed:", err)
+	}
+	rw := std.file.(*rotateWriter)
+
+	if err := Setup(Config{Level: "info"}); err != nil {
+		t.Fatal("Setup err:", err)
+	}
+	if _, err := rw.file.Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("log file should be closed, stat err = %v", err)
+	}
+	if err := Setup(Config{Level: "warn"}); err != nil {
+		t.Fatal("Setup err:", err)
+	}
+	if _, err := os.Stdout.Stat(); err != nil {
+		t.Error("stdout should not be closed:", err)
+	}
+}
@@ -0,0 +1,94 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 按大小切分的日志文件，超过maxSize时当前文件改名为file.1，旧的依次后移
+type rotateWriter struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotateWriter(path string, maxSize int64, maxBackups int) (*rotateWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	w := &rotateWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// 打开日志文件，已经存在时追加
+func (w *rotateWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// 写日志，由output的锁保护，不需要单独加锁
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintln(os.Stderr, "rotate log file err:", err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// 切分日志文件，先改名再打开新文件，旧文件在新文件打开之后才关闭
+// 任何一步失败都以追加方式重新打开原路径，保证日志可以继续写
+func (w *rotateWriter) rotate() error {
+	old := w.file
+	err := w.shift()
+	if err == nil {
+		err = w.open()
+	}
+	if err != nil {
+		if reopenErr := w.open(); reopenErr != nil {
+			//原路径也打不开，继续写旧的文件
+			w.file = old
+			return err
+		}
+	}
+	old.Close()
+	return err
+}
+
+// 旧的备份依次后移，当前文件改名为file.1，不保留备份时直接删除
+func (w *rotateWriter) shift() error {
+	if w.maxBackups <= 0 {
+		return os.Remove(w.path)
+	}
+	os.Remove(w.backupName(w.maxBackups))
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		os.Rename(w.backupName(i), w.backupName(i+1))
+	}
+	return os.Rename(w.path, w.backupName(1))
+}
+
+func (w *rotateWriter) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+func (w *rotateWriter) Close() error {
+	return w.file.Close()
+}
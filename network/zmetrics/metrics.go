@@ -0,0 +1,206 @@
+package zmetrics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"sync"
+)
+
+// 默认的耗时分布区间(秒)
+var DefBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// 带标签的计数器，只增不减
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	lock   sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+// 创建一个计数器并注册到默认注册表
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]*counterValue),
+	}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// 增加计数，labelValues的顺序和创建时的labelNames一致
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	cv, ok := c.values[key]
+	if !ok {
+		cv = &counterValue{labelValues: labelValues}
+		c.values[key] = cv
+	}
+	cv.value += v
+}
+
+// 计数加1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) metricName() string {
+	return c.name
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range sortedKeys(c.values) {
+		cv := c.values[key]
+		writeSample(w, c.name, c.labelNames, cv.labelValues, cv.value)
+	}
+}
+
+// 带标签的直方图，统计数值的分布，例如处理耗时
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	lock   sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64 //每个区间的数量，不累加
+	sum         float64
+	count       uint64
+}
+
+// 创建一个直方图并注册到默认注册表，buckets为各个区间的上限
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		values:     make(map[string]*histogramValue),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// 记录一个数值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.sum += v
+	hv.count++
+}
+
+func (h *HistogramVec) metricName() string {
+	return h.name
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	labelNames := append(append([]string(nil), h.labelNames...), "le")
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+		labelValues := append(append([]string(nil), hv.labelValues...), "")
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += hv.counts[i]
+			labelValues[len(labelValues)-1] = formatFloat(upper)
+			writeSample(w, h.name+"_bucket", labelNames, labelValues, float64(cumulative))
+		}
+		labelValues[len(labelValues)-1] = formatFloat(math.Inf(1))
+		writeSample(w, h.name+"_bucket", labelNames, labelValues, float64(hv.count))
+		writeSample(w, h.name+"_sum", h.labelNames, hv.labelValues, hv.sum)
+		writeSample(w, h.name+"_count", h.labelNames, hv.labelValues, float64(hv.count))
+	}
+}
+
+// 输出时才计算数值的指标，例如链接数量
+type funcCollector struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	collect    func(emit func(value float64, labelValues ...string))
+}
+
+// 创建一个输出时调用fn获取数值的gauge并注册到默认注册表
+func NewGaugeFunc(name, help string, fn func() float64) {
+	NewGaugeVecFunc(name, help, nil, func(emit func(value float64, labelValues ...string)) {
+		emit(fn())
+	})
+}
+
+// 创建一个输出时调用fn获取数值的counter并注册到默认注册表，fn返回的数值需要只增不减
+func NewCounterFunc(name, help string, fn func() float64) {
+	DefaultRegistry.register(&funcCollector{
+		name: name,
+		help: help,
+		typ:  "counter",
+		collect: func(emit func(value float64, labelValues ...string)) {
+			emit(fn())
+		},
+	})
+}
+
+// 创建一个带标签的gauge并注册到默认注册表，输出时调用collect，collect中对每组标签调用emit
+func NewGaugeVecFunc(name, help string, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	DefaultRegistry.register(&funcCollector{
+		name:       name,
+		help:       help,
+		typ:        "gauge",
+		labelNames: labelNames,
+		collect:    collect,
+	})
+}
+
+func (f *funcCollector) metricName() string {
+	return f.name
+}
+
+func (f *funcCollector) write(w io.Writer) {
+	writeHeader(w, f.name, f.help, f.typ)
+	f.collect(func(value float64, labelValues ...string) {
+		writeSample(w, f.name, f.labelNames, labelValues, value)
+	})
+}
+
+// map的key排序，保证每次输出的顺序一致
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package zmetrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 计数器、直方图和gauge按照Prometheus文本格式输出
+func TestRegistryWrite(t *testing.T) {
+	counter := NewCounterVec("test_requests_total", "Test requests.", "msgid")
+	counter.Inc("2")
+	counter.Add(2, "2")
+	counter.Inc(`a"b`)
+	histogram := NewHistogramVec("test_duration_seconds", "Test duration.", []float64{0.1, 1}, "msgid")
+	histogram.Observe(0.05, "2")
+	histogram.Observe(0.5, "2")
+	histogram.Observe(5, "2")
+	NewGaugeFunc("test_connections", "Test connections.", func() float64 { return 3 })
+
+	var buf bytes.Buffer
+	DefaultRegistry.Write(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{msgid="2"} 3` + "\n",
+		`test_requests_total{msgid="a\"b"} 1` + "\n",
+		"# TYPE test_duration_seconds histogram\n",
+		`test_duration_seconds_bucket{msgid="2",le="0.1"} 1` + "\n",
+		`test_duration_seconds_bucket{msgid="2",le="1"} 2` + "\n",
+		`test_duration_seconds_bucket{msgid="2",le="+Inf"} 3` + "\n",
+		`test_duration_seconds_sum{msgid="2"} 5.55` + "\n",
+		`test_duration_seconds_count{msgid="2"} 3` + "\n",
+		"test_connections 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
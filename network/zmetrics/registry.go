@@ -0,0 +1,114 @@
+package zmetrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 一个指标，按照Prometheus文本格式输出自己
+type collector interface {
+	//指标名字
+	metricName() string
+	//输出HELP TYPE以及所有样本
+	write(w io.Writer)
+}
+
+// 指标注册表
+type Registry struct {
+	lock       sync.RWMutex
+	collectors map[string]collector
+}
+
+// 默认的注册表，New开头的方法创建的指标都注册到这里
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// 注册一个指标，同名的指标会被替换
+func (r *Registry) register(c collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.collectors[c.metricName()] = c
+}
+
+// 按名字顺序输出所有指标
+func (r *Registry) Write(w io.Writer) {
+	r.lock.RLock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.lock.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].metricName() < collectors[j].metricName()
+	})
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	bw.Flush()
+}
+
+// 输出指标的http处理方法
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// 默认注册表的http处理方法
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// 输出指标的HELP和TYPE
+func writeHeader(w io.Writer, name, help, typ string) {
+	io.WriteString(w, "# HELP "+name+" "+strings.ReplaceAll(help, "\n", " ")+"\n")
+	io.WriteString(w, "# TYPE "+name+" "+typ+"\n")
+}
+
+// 输出一个样本 name{label="value",...} value
+func writeSample(w io.Writer, name string, labelNames, labelValues []string, value float64) {
+	io.WriteString(w, name)
+	if len(labelNames) > 0 {
+		io.WriteString(w, "{")
+		for i, labelName := range labelNames {
+			if i > 0 {
+				io.WriteString(w, ",")
+			}
+			labelValue := ""
+			if i < len(labelValues) {
+				labelValue = labelValues[i]
+			}
+			io.WriteString(w, labelName+`="`+escapeLabelValue(labelValue)+`"`)
+		}
+		io.WriteString(w, "}")
+	}
+	io.WriteString(w, " "+formatFloat(value)+"\n")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// 多个标签值拼接成map的key
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
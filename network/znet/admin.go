@@ -0,0 +1,41 @@
+package znet
+
+import (
+	"fmt"
+	"net/http"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+	"strings"
+)
+
+// 管理接口只监听本机地址，不和对外的指标端口共用，反向代理转发的请求也无法访问
+const adminHost = "127.0.0.1"
+
+// 添加管理接口，路径为AdminPath加上path，例如 s.AddAdminHandler("/bans", handler)
+// 管理接口监听127.0.0.1:AdminPort，需要在Start之前调用
+func (s *Server) AddAdminHandler(path string, handler http.HandlerFunc) {
+	if s.adminHandlers == nil {
+		s.adminHandlers = make(map[string]http.HandlerFunc)
+	}
+	s.adminHandlers[path] = handler
+}
+
+// 开启管理接口监听，注册框架和业务的管理接口
+func (s *Server) startAdmin() {
+	adminPath := strings.TrimSuffix(utils.Global().AdminPath, "/")
+	mux := http.NewServeMux()
+	mux.HandleFunc(adminPath+"/reload", s.handleReload)
+	for path, handler := range s.adminHandlers {
+		mux.HandleFunc(adminPath+path, handler)
+	}
+	s.adminServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", adminHost, utils.Global().AdminPort),
+		Handler: mux,
+	}
+	go func() {
+		zlog.Info("start admin server", "addr", s.adminServer.Addr, "path", adminPath)
+		if err := s.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zlog.Error("admin server error", "err", err)
+		}
+	}()
+}
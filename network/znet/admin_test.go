@@ -0,0 +1,58 @@
+package znet
+
+import (
+	"fmt"
+	"net/http"
+	"server-1.1.0/network/utils"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 管理接口使用单独的端口，只监听127.0.0.1，指标端口上访问不到
+func TestServerAdmin(t *testing.T) {
+	adminPort, metricsPort := freePort(t), freePort(t)
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.Host = "127.0.0.1"
+		g.TcpPort = freePort(t)
+		g.WsPort = 0
+		g.MetricsPort = metricsPort
+		g.AdminPort = adminPort
+		g.AdminPath = "/admin"
+	})
+	s := NewServer().(*Server)
+	s.AddAdminHandler("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	if err := s.Start(); err != nil {
+		t.Fatal("start server err:", err)
+	}
+	t.Cleanup(s.Stop)
+
+	if !strings.HasPrefix(s.adminServer.Addr, "127.0.0.1:") {
+		t.Errorf("admin addr = %s, want loopback", s.adminServer.Addr)
+	}
+	get := func(port int, path string) int {
+		url := fmt.Sprintf("http://127.0.0.1:%d%s", port, path)
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get(url); err == nil {
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatal("get", url, "err:", err)
+		return 0
+	}
+	if code := get(adminPort, "/admin/ping"); code != http.StatusOK {
+		t.Errorf("admin handler code = %d, want 200", code)
+	}
+	if code := get(metricsPort, "/admin/ping"); code != http.StatusNotFound {
+		t.Errorf("admin handler on metrics port code = %d, want 404", code)
+	}
+	if code := get(metricsPort, utils.Global().MetricsPath); code != http.StatusOK {
+		t.Errorf("metrics code = %d, want 200", code)
+	}
+}
@@ -0,0 +1,101 @@
+package znet
+
+import (
+	"encoding/binary"
+	"net"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"strconv"
+	"time"
+)
+
+// 拒绝链接的原因，通过RefuseMsgID发送给客户端
+// 自定义的准入检查可以使用RefuseCustom之后的值
+const (
+	RefuseNone            uint32 = 0   //允许链接
+	RefuseServerFull      uint32 = 1   //链接数已经达到MaxConn
+	RefuseMaintenance     uint32 = 2   //服务器维护中
+	RefuseBannedIP        uint32 = 3   //IP被禁止
+	RefuseVersionMismatch uint32 = 4   //客户端版本不匹配
+	RefuseTooManyConns    uint32 = 5   //同一个IP的链接数超过MaxConnPerIP
+	RefuseCustom          uint32 = 100 //自定义原因的起始值
+)
+
+// 发送拒绝消息的最长等待时间，避免客户端不读数据时阻塞Accept
+const refuseWriteTimeout = time.Second
+
+// 拒绝原因的名字，用于日志和指标
+func RefuseReasonName(reason uint32) string {
+	switch reason {
+	case RefuseNone:
+		return "none"
+	case RefuseServerFull:
+		return "server_full"
+	case RefuseMaintenance:
+		return "maintenance"
+	case RefuseBannedIP:
+		return "banned_ip"
+	case RefuseVersionMismatch:
+		return "version_mismatch"
+	case RefuseTooManyConns:
+		return "too_many_conns"
+	}
+	return strconv.FormatUint(uint64(reason), 10)
+}
+
+// 添加准入检查，在创建Connection之前按添加顺序调用，需要在Start之前调用
+func (s *Server) AddAdmitHook(hook ziface.AdmitHook) {
+	s.admitHooks = append(s.admitHooks, hook)
+}
+
+// 更新IP黑白名单和每个IP的链接数限制，运行时调用只影响新的链接
+func (s *Server) UpdateIPFilter(allow, deny []string, maxConnPerIP int) error {
+	return s.ipFilter.update(allow, deny, maxConnPerIP)
+}
+
+// 判断是否允许新的链接，返回拒绝原因
+// 允许时占用对端IP的一个链接名额，链接断开时在CallOnConnStop中释放
+func (s *Server) admit(conn net.Conn) uint32 {
+	if utils.Global().Maintenance {
+		return RefuseMaintenance
+	}
+	ip := remoteIP(conn.RemoteAddr())
+	if ip != nil && !s.ipFilter.allowed(ip) {
+		return RefuseBannedIP
+	}
+	//设置最大连接个数的判断，如果超过最大连接，那么则拒绝此新的连接
+	if s.ConnMgr.Len() >= utils.Global().MaxConn {
+		return RefuseServerFull
+	}
+	for _, hook := range s.admitHooks {
+		if reason := hook(conn); reason != RefuseNone {
+			return reason
+		}
+	}
+	if ip != nil && !s.ipFilter.acquire(ip) {
+		return RefuseTooManyConns
+	}
+	return RefuseNone
+}
+
+// 给客户端发送拒绝消息之后关闭链接，数据为拒绝原因(uint32小端)
+func (s *Server) refuse(conn net.Conn, reason uint32) {
+	defer conn.Close()
+	//读写都设置超时，TLS链接写之前需要先读客户端的握手，客户端不发送握手时不会一直阻塞
+	conn.SetDeadline(time.Now().Add(refuseWriteTimeout))
+	refusedTotal.Inc(RefuseReasonName(reason))
+	zlog.Warn("refuse connection", "remoteAddr", conn.RemoteAddr().String(),
+		"reason", RefuseReasonName(reason), "connNum", s.ConnMgr.Len())
+
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, reason)
+	binaryMsg, err := s.Packet.Pack(NewMsgPackage(RefuseMsgID, data))
+	if err != nil {
+		zlog.Error("pack refuse msg error", "err", err)
+		return
+	}
+	if _, err := conn.Write(binaryMsg); err != nil {
+		zlog.Debug("send refuse msg error", "remoteAddr", conn.RemoteAddr().String(), "err", err)
+	}
+}
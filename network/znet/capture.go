@@ -0,0 +1,141 @@
+package znet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"time"
+)
+
+// 抓包记录的方向
+const (
+	CaptureIn  = "in"  //从客户端收到的消息
+	CaptureOut = "out" //发送给客户端的消息
+)
+
+// 一条抓包记录，抓包文件每行一条json
+// 收到的数据是解压之后的，发送的数据是压缩之前的
+type CaptureRecord struct {
+	Time   time.Time `json:"time"`
+	Dir    string    `json:"dir"`
+	UDP    bool      `json:"udp,omitempty"`
+	ConnID uint32    `json:"connID"`
+	MsgID  uint32    `json:"msgID"`
+	Seq    uint32    `json:"seq,omitempty"`
+	Data   []byte    `json:"data"`
+}
+
+// 读取抓包文件中的全部记录
+func ReadCapture(r io.Reader) ([]*CaptureRecord, error) {
+	var records []*CaptureRecord
+	dec := json.NewDecoder(r)
+	for {
+		record := &CaptureRecord{}
+		if err := dec.Decode(record); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// 设置抓包时的脱敏函数，需要在Start之前调用
+func (s *Server) SetCaptureRedactor(redactor ziface.CaptureRedactor) {
+	s.captureRedactor = redactor
+}
+
+// 调用脱敏函数，没有设置时原样返回data
+func (s *Server) RedactCapture(dir string, msgID uint32, data []byte) []byte {
+	if s.captureRedactor == nil {
+		return data
+	}
+	return s.captureRedactor(dir, msgID, data)
+}
+
+// 开始把当前链接收发的消息记录到文件中，已经在抓包时切换到新的文件
+// 抓包文件可能包含玩家的隐私数据，只有当前用户可以读写
+func (c *Connection) StartCapture(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	c.captureLock.Lock()
+	old := c.captureFile
+	c.captureFile = file
+	c.captureEnc = json.NewEncoder(file)
+	c.captureLock.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	c.logger.Info("start capture", "path", path)
+	return nil
+}
+
+// 停止抓包并关闭文件
+func (c *Connection) StopCapture() {
+	c.captureLock.Lock()
+	defer c.captureLock.Unlock()
+	if c.captureFile == nil {
+		return
+	}
+	c.captureFile.Close()
+	c.captureFile = nil
+	c.captureEnc = nil
+}
+
+// 链接的抓包文件路径，在CaptureDir下，文件名带上链接ID和时间，没有配置CaptureDir时返回空
+func CapturePath(connID uint32) string {
+	dir := utils.Global().CaptureDir
+	if dir == "" {
+		return ""
+	}
+	name := fmt.Sprintf("conn-%d-%s.jsonl", connID, time.Now().Format("20060102-150405"))
+	return filepath.Join(dir, name)
+}
+
+// 配置了CaptureAll时，链接启动之后自动抓包
+func (c *Connection) startCaptureByConfig() {
+	if !utils.Global().CaptureAll {
+		return
+	}
+	path := CapturePath(c.ConnID)
+	if path == "" {
+		return
+	}
+	if err := c.StartCapture(path); err != nil {
+		c.logger.Warn("start capture error", "err", err)
+	}
+}
+
+// 记录一条消息，没有开启抓包时直接返回，写入之前先去掉敏感字段
+func (c *Connection) record(dir string, udp bool, msgID uint32, seq uint32, data []byte) {
+	c.captureLock.Lock()
+	defer c.captureLock.Unlock()
+	if c.captureEnc == nil {
+		return
+	}
+	data = c.TcpServer.RedactCapture(dir, msgID, data)
+	err := c.captureEnc.Encode(&CaptureRecord{
+		Time:   time.Now(),
+		Dir:    dir,
+		UDP:    udp,
+		ConnID: c.ConnID,
+		MsgID:  msgID,
+		Seq:    seq,
+		Data:   data,
+	})
+	if err != nil {
+		c.logger.Warn("write capture error, stop capture", "err", err)
+		c.captureFile.Close()
+		c.captureFile = nil
+		c.captureEnc = nil
+	}
+}
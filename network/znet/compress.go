@@ -0,0 +1,64 @@
+package znet
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"server-1.1.0/network/utils"
+)
+
+// 消息标志位，需要使用带标志位的封包格式(flag)
+const (
+	MsgFlagCompressed uint8 = 1 << 0 //数据部分经过zlib压缩
+	MsgFlagSeq        uint8 = 1 << 1 //包头携带4字节请求序号，回复时带上同样的序号
+)
+
+// 能够携带消息标志位的封包格式需要实现该接口，压缩等功能依赖标志位
+type flagCarrier interface {
+	SupportFlags() bool
+}
+
+// 判断封包格式是否能携带消息标志位
+func supportFlags(dp interface{}) bool {
+	fc, ok := dp.(flagCarrier)
+	return ok && fc.SupportFlags()
+}
+
+// 是否需要压缩发送的数据
+func needCompress(dp interface{}, data []byte) bool {
+	threshold := utils.Global().CompressThreshold
+	return threshold > 0 && uint32(len(data)) > threshold && supportFlags(dp)
+}
+
+// 使用zlib压缩消息数据
+func compressData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 解压消息数据，解压之后的长度不能超过MaxDecompressSize，防止恶意的压缩包撑爆内存
+func decompressData(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	limit := int64(utils.Global().MaxDecompressSize)
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, errors.New("too Large msg data after decompress!")
+	}
+	return out, nil
+}
@@ -1,41 +1,79 @@
 package znet
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
 	"net"
+	"os"
 	"server-1.1.0/network/utils"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 带缓冲发送队列满时的处理策略，在zinx.json的SendOverflowPolicy中配置
+const (
+	OverflowDisconnect = "disconnect"  //断开链接(默认)，用于必须送达的可靠消息
+	OverflowDropOldest = "drop-oldest" //丢弃队列中最旧的消息
+	OverflowBlock      = "block"       //阻塞等待队列有空位
 )
 
 // 链接模块
 type Connection struct {
 	//当前Conn隶属哪个Server
 	TcpServer ziface.IServer
-	//当前链接的socket TCP套接字
-	Conn *net.TCPConn
+	//当前链接的socket套接字，TCP链接或者包装之后的WebSocket链接
+	Conn net.Conn
 	//链接的ID
 	ConnID uint32
 	//当前链接的状态
 	isClosed bool
+	//保护链接状态的锁，Stop可能同时被Reader和Server关闭流程调用
+	closeLock sync.Mutex
 	//当前绑定的处理业务方法API
 	handleAPI ziface.HandleFunc
-	//告知当前链接已经退出、停止 channel(chan 类型 里面是bool值） 由Reader告知
+	//Start启动的读写和心跳goroutine，Server关闭时等待它们退出
+	wg sync.WaitGroup
+	//告知当前链接已经退出、停止 channel(chan 类型 里面是bool值） 链接停止时关闭
 	ExitChan chan bool
 	//无缓冲管道，用于读写goroutine之间的信息通信
 	msgChan chan []byte
+	//有缓冲管道，SendBuffMsg使用，队列满时按照SendOverflowPolicy处理
+	msgBuffChan chan []byte
+	//有缓冲管道，SendDroppableMsg使用，队列满时丢弃最旧的消息
+	msgDropChan chan []byte
+	//最后一个消息，SendAndStop使用，Writer发送之后关闭链接
+	lastMsgChan chan []byte
 	//消息的管理MsgID和对应的处理业务API关系
 	Msghandler ziface.IMsgHanle
 	//链接属性集合
 	property map[string]interface{}
 	//保护链接属性的锁
 	propertyLock sync.RWMutex
+	//最后一次收到客户端消息的时间(UnixNano)
+	lastActivity int64
+	//入站消息限流器
+	limiter *rateLimiter
+	//分配Worker使用的key
+	dispatchKey uint32
+	//收到和发送的字节数
+	bytesReceived uint64
+	bytesSent     uint64
+	//带有connID的日志
+	logger *zlog.Logger
+	//UDP会话，没有开启UDP通道时为nil，由closeLock保护
+	udp *udpSession
+	//抓包文件，没有开启抓包时为nil
+	captureLock sync.Mutex
+	captureFile *os.File
+	captureEnc  *json.Encoder
 }
 
 // 初始化链接模块的方法
-func NewConnection(server ziface.IServer, conn *net.TCPConn, connID uint32, msgHandler ziface.IMsgHanle) *Connection {
+func NewConnection(server ziface.IServer, conn net.Conn, connID uint32, msgHandler ziface.IMsgHanle) *Connection {
 	c := &Connection{
 		TcpServer:  server,
 		Conn:       conn,
@@ -43,9 +81,16 @@ func NewConnection(server ziface.IServer, conn *net.TCPConn, connID uint32, msgH
 		Msghandler: msgHandler,
 		isClosed:   false,
 		msgChan:    make(chan []byte),
-		ExitChan:   make(chan bool, 1),
+		ExitChan:   make(chan bool),
 		property:   make(map[string]interface{}),
+		limiter:    newRateLimiter(),
 	}
+	c.dispatchKey = connID
+	c.logger = zlog.With("connID", connID)
+	c.msgBuffChan = make(chan []byte, utils.Global().MaxMsgChanLen)
+	c.msgDropChan = make(chan []byte, utils.Global().MaxMsgChanLen)
+	c.lastMsgChan = make(chan []byte, 1)
+	c.updateActivity()
 	//将conn加入connManager中
 	c.TcpServer.GetConnMgr().Add(c)
 	return c
@@ -54,78 +99,101 @@ func NewConnection(server ziface.IServer, conn *net.TCPConn, connID uint32, msgH
 
 // 业务读数据的方法
 func (c *Connection) StartReader() {
-	fmt.Println("[reader goroutine is running..]")
-	defer fmt.Println("[Reader is exit],connID= ", c.ConnID, "remote addr is", c.RemoteAddr().String())
+	c.logger.Debug("reader goroutine is running")
+	defer c.logger.Debug("reader is exit", "remoteAddr", c.RemoteAddr().String())
 	defer c.Stop()
+	//创建一个拆包解包的对象
+	dp := c.TcpServer.GetPacket()
+	//带缓冲的读，变长包头逐字节读取时不会每次都进行系统调用
+	reader := bufio.NewReader(&countReader{c: c})
 	for {
 		////读取客户端的数据到buf中，最大配置文件获取
-		//buf := make([]byte, utils.GlobalObject.MaxPacketSize)
+		//buf := make([]byte, utils.Global().MaxPacketSize)
 		//_, err := c.Conn.Read(buf)
 		//if err != nil {
 		//	fmt.Println("recv buf err", err)
 		//	//这里不用return 不用break 用continue 原因在于还要接着执行下面的方法
 		//	continue
 		//}
-		//创建一个拆包解包的对象
-		dp := NewDataPack()
-		//读取客户端的Msg Head二进制流8字节
-		headData := make([]byte, dp.GetHeadLen())
-		if _, err := io.ReadFull(c.GetTcpConnection(), headData); err != nil {
-			fmt.Println("read msg head error", err)
-			break
-		}
-
-		//拆包得msgid和msgDatalen放在msg消息中
-		msg, err := dp.Unpack(headData)
+		//使用Server配置的封包格式，从数据流中读出一个完整的消息
+		msg, err := dp.Unpack(reader)
 		if err != nil {
-			fmt.Println("unpack error", err)
+			c.logger.Debug("unpack error", "err", err)
 			break
 		}
+		//压缩过的数据先解压，再交给业务处理
+		if msg.GetFlags()&MsgFlagCompressed != 0 {
+			data, err := decompressData(msg.GetData())
+			if err != nil {
+				c.logger.Warn("decompress msg error", "msgID", msg.GetMsgId(), "err", err)
+				break
+			}
+			msg.SetData(data)
+			msg.SetDataLen(uint32(len(data)))
+			msg.SetFlags(msg.GetFlags() &^ MsgFlagCompressed)
+		}
+		c.record(CaptureIn, false, msg.GetMsgId(), msg.GetSeq(), msg.GetData())
+		c.updateActivity()
 
-		//根据datalen再次读data放在msg.data中
-		var data []byte
-		if msg.GetDataLen() > 0 {
-			data = make([]byte, msg.GetDataLen())
-			if _, err := io.ReadFull(c.GetTcpConnection(), data); err != nil {
-				fmt.Println("read msg data error", err)
+		//超过限流的消息直接丢弃，不交给工作池，心跳消息同样计入链接的限流
+		if !c.checkRateLimit(msg.GetMsgId()) {
+			if c.IsClosed() {
 				break
 			}
+			continue
+		}
+		//心跳消息由框架直接处理，不交给业务路由
+		if c.handleHeartbeat(msg) {
+			continue
 		}
-		msg.SetData(data)
 
 		//得到当前conn数据的Request请求数据
-		req := Request{
+		c.dispatch(&Request{
 			conn: c,
 			msg:  msg,
-		}
-
-		if utils.GlobalObject.WorkerPoolSize > 0 {
-			//已经开启了工作池机制，将消息送给Worker工作池处理即可
-			c.Msghandler.SendMsgToTaskQueue(&req)
-		} else {
-			//从路由中，找到注册绑定的Conn对应的Router调用
-			//根据绑定好的MsgID找到对应处理api业务执行
-
-			go c.Msghandler.DoMsgHandler(&req)
-
-		}
+		})
+	}
+}
 
+// 把请求交给业务处理，TCP和UDP收到的消息都走这里
+func (c *Connection) dispatch(req *Request) {
+	if utils.Global().WorkerPoolSize > 0 {
+		//已经开启了工作池机制，将消息送给Worker工作池处理即可
+		c.Msghandler.SendMsgToTaskQueue(req)
+	} else {
+		//从路由中，找到注册绑定的Conn对应的Router调用
+		//根据绑定好的MsgID找到对应处理api业务执行
+		go c.Msghandler.DoMsgHandler(req)
 	}
 }
 
 // 写消息的goroutine，专门发送给客户端消息的模块
 func (c *Connection) StartWriter() {
-	fmt.Println("[Writer Goroutine is running]")
-	defer fmt.Println("[conn Writer exit]", c.RemoteAddr().String())
+	c.logger.Debug("writer goroutine is running")
+	defer c.logger.Debug("writer is exit", "remoteAddr", c.RemoteAddr().String())
 	//不断的阻塞的等待channel的消息，进行写给客户端
 	for {
 		select {
 		case data := <-c.msgChan:
 			//有数据要写给客户端
-			if _, err := c.Conn.Write(data); err != nil {
-				fmt.Println("Send data err", err)
+			if !c.write(data) {
+				return
+			}
+		case data := <-c.msgBuffChan:
+			if !c.write(data) {
 				return
 			}
+		case data := <-c.msgDropChan:
+			if !c.write(data) {
+				return
+			}
+		case data := <-c.lastMsgChan:
+			//先发送已经在队列中的可靠消息，再发送最后一个消息，然后关闭链接
+			c.flushBuff()
+			c.Conn.SetWriteDeadline(time.Now().Add(refuseWriteTimeout))
+			c.write(data)
+			c.Stop()
+			return
 		case <-c.ExitChan:
 			//代表Reader已经退出，此时Writer也要退出
 			return
@@ -133,43 +201,139 @@ func (c *Connection) StartWriter() {
 	}
 }
 
+// 发送带缓冲队列中剩余的数据，不等待新的数据
+func (c *Connection) flushBuff() {
+	for {
+		select {
+		case data := <-c.msgBuffChan:
+			if !c.write(data) {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// 把数据写给客户端，写失败说明链接已经不可用，停止链接
+func (c *Connection) write(data []byte) bool {
+	n, err := c.Conn.Write(data)
+	c.addBytesSent(n)
+	if err != nil {
+		c.logger.Debug("send data error", "err", err)
+		c.Stop()
+		return false
+	}
+	return true
+}
+
 func (c *Connection) Start() {
-	fmt.Println("Conn Start()...ConnID=", c.ConnID)
+	c.logger.Info("conn start", "remoteAddr", c.RemoteAddr().String())
+	//配置了CaptureAll时开始抓包
+	c.startCaptureByConfig()
 	//启动从当前链接的读数据业务
-	go c.StartReader()
+	c.goWait(c.StartReader)
 	// 启动从当前链接写数据的业务
-	go c.StartWriter()
+	c.goWait(c.StartWriter)
+	// 启动空闲检测
+	if utils.Global().MaxIdleTime > 0 {
+		c.goWait(c.StartHeartbeat)
+	}
 	//按照开发者传递进来的 创建链接之后需要调用的处理业务，执行对应Hook函数
 	c.TcpServer.CallOnConnStart(c)
 
 }
+
+// 启动一个由wg等待的goroutine
+func (c *Connection) goWait(f func()) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		f()
+	}()
+}
+
 func (c *Connection) Stop() {
-	fmt.Println("Conn Stop()...ConnID=", c.ConnID)
+	c.logger.Info("conn stop")
 
+	c.closeLock.Lock()
 	//如果当前链接已经关闭
 	if c.isClosed == true {
+		c.closeLock.Unlock()
 		return
 	}
 	c.isClosed = true
+	c.closeUnreliable()
+	c.closeLock.Unlock()
 
 	//调用开发者注册进来的 销毁链接之前需要调用的处理业务，执行对应Hook函数
 	c.TcpServer.CallOnConnStop(c)
 
 	//关闭socket链接
 	c.Conn.Close()
-	//告知Writer关闭
-	c.ExitChan <- true
+	//告知Writer、心跳检测以及阻塞在发送上的goroutine退出
+	//发送管道不关闭，避免关闭之后还有goroutine向管道发送数据引起panic
+	close(c.ExitChan)
 	//将当前连接从ConnMgr中摘除
 	c.TcpServer.GetConnMgr().Remove(c)
+	c.StopCapture()
 
-	//回收资源
-	close(c.ExitChan)
-	close(c.msgChan)
+}
+
+// 设置分配Worker使用的key
+func (c *Connection) SetDispatchKey(key uint32) {
+	atomic.StoreUint32(&c.dispatchKey, key)
+}
+
+// 获取分配Worker使用的key
+func (c *Connection) GetDispatchKey() uint32 {
+	return atomic.LoadUint32(&c.dispatchKey)
+}
+
+// 从客户端收到的字节数
+func (c *Connection) BytesReceived() uint64 {
+	return atomic.LoadUint64(&c.bytesReceived)
+}
+
+// 发送给客户端的字节数
+func (c *Connection) BytesSent() uint64 {
+	return atomic.LoadUint64(&c.bytesSent)
+}
+
+func (c *Connection) addBytesReceived(n int) {
+	if n > 0 {
+		atomic.AddUint64(&c.bytesReceived, uint64(n))
+		bytesReceivedTotal.Add(float64(n))
+	}
+}
+
+func (c *Connection) addBytesSent(n int) {
+	if n > 0 {
+		atomic.AddUint64(&c.bytesSent, uint64(n))
+		bytesSentTotal.Add(float64(n))
+	}
+}
+
+// 统计收到字节数的Reader
+type countReader struct {
+	c *Connection
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	n, err := r.c.Conn.Read(p)
+	r.c.addBytesReceived(n)
+	return n, err
+}
 
+// 当前链接是否已经关闭
+func (c *Connection) IsClosed() bool {
+	c.closeLock.Lock()
+	defer c.closeLock.Unlock()
+	return c.isClosed
 }
 
 // 获取当前链接的绑定socket conn
-func (c *Connection) GetTcpConnection() *net.TCPConn {
+func (c *Connection) GetConnection() net.Conn {
 	return c.Conn
 }
 
@@ -183,28 +347,160 @@ func (c *Connection) RemoteAddr() net.Addr {
 	return c.Conn.RemoteAddr()
 }
 
+// 将要发送给客户端的数据进行封包 msgdaatalen | MsgID | data
+func (c *Connection) packMsg(msgId uint32, data []byte) ([]byte, error) {
+	return c.packSeqMsg(0, msgId, data)
+}
+
+// 封包并带上请求序号，seq为0或者封包格式不支持标志位时不携带序号
+func (c *Connection) packSeqMsg(seq uint32, msgId uint32, data []byte) ([]byte, error) {
+	dp := c.TcpServer.GetPacket()
+	c.record(CaptureOut, false, msgId, seq, data)
+
+	msg := NewMsgPackage(msgId, data)
+	//数据较大并且封包格式支持标志位时，压缩之后再发送
+	if needCompress(dp, data) {
+		compressed, err := compressData(data)
+		if err != nil {
+			c.logger.Warn("compress error", "msgID", msgId, "err", err)
+		} else if len(compressed) < len(data) {
+			msg = NewMsgPackage(msgId, compressed)
+			msg.SetFlags(MsgFlagCompressed)
+		}
+	}
+	if seq != 0 && supportFlags(dp) {
+		msg.SetSeq(seq)
+		msg.SetFlags(msg.GetFlags() | MsgFlagSeq)
+	}
+	binaryMsg, err := dp.Pack(msg)
+	if err != nil {
+		c.logger.Error("pack error", "msgID", msgId, "err", err)
+		return nil, errors.New("pack error msg")
+	}
+	return binaryMsg, nil
+}
+
 // 提供一个SendMsg方法 将我们要发送给客户端的数据，先进行封包，再发送
+// 无缓冲，会阻塞到Writer取走数据为止
 func (c *Connection) SendMsg(msgId uint32, data []byte) error {
-	if c.isClosed == true {
+	if c.IsClosed() {
 		return errors.New("Connection closed when send msg")
 	}
-	//将data进行封包 msgdaatalen | MsgID | data
-	dp := NewDataPack()
+	binaryMsg, err := c.packMsg(msgId, data)
+	if err != nil {
+		return err
+	}
+	//将数据发送给Writer，链接关闭时不再阻塞
+	select {
+	case c.msgChan <- binaryMsg:
+		return nil
+	case <-c.ExitChan:
+		return errors.New("Connection closed when send msg")
+	}
+}
+
+// 带缓冲发送，不等待Writer，发送队列满时按照SendOverflowPolicy处理
+func (c *Connection) SendBuffMsg(msgId uint32, data []byte) error {
+	if c.IsClosed() {
+		return errors.New("Connection closed when send buff msg")
+	}
+	binaryMsg, err := c.packMsg(msgId, data)
+	if err != nil {
+		return err
+	}
+	return c.sendBuff(msgId, binaryMsg)
+}
 
-	binaryMsg, err := dp.Pack(NewMsgPackage(msgId, data))
+// 带缓冲发送请求的回复，回复和SendBuffMsg一样按照SendOverflowPolicy处理
+func (c *Connection) SendSeqMsg(seq uint32, msgId uint32, data []byte) error {
+	if c.IsClosed() {
+		return errors.New("Connection closed when send seq msg")
+	}
+	binaryMsg, err := c.packSeqMsg(seq, msgId, data)
 	if err != nil {
-		fmt.Println("Pack error msg id =", msgId)
-		return errors.New("pack error msg")
-	}
-	//将数据发送到客户端
-	//if _, err := c.Conn.Write(binaryMsg); err != nil {
-	//	fmt.Println("Write msg id ", msgId, "error:", err)
-	//	return errors.New("conn Write error")
-	//}
-	c.msgChan <- binaryMsg
+		return err
+	}
+	return c.sendBuff(msgId, binaryMsg)
+}
+
+// 把封包之后的数据放入带缓冲的发送队列
+func (c *Connection) sendBuff(msgId uint32, binaryMsg []byte) error {
+	switch utils.Global().SendOverflowPolicy {
+	case OverflowBlock:
+		select {
+		case c.msgBuffChan <- binaryMsg:
+			return nil
+		case <-c.ExitChan:
+			return errors.New("Connection closed when send buff msg")
+		}
+	case OverflowDropOldest:
+		dropOldestSend(c.msgBuffChan, binaryMsg)
+		return nil
+	default:
+		select {
+		case c.msgBuffChan <- binaryMsg:
+			return nil
+		default:
+			//接收端太慢，可靠消息无法保证送达，断开链接让客户端重连
+			c.logger.Warn("send buff full, stop connection", "msgID", msgId)
+			go c.Stop()
+			return errors.New("send buff full, connection closed")
+		}
+	}
+}
+
+// 发送最后一个消息之后关闭链接，例如拒绝客户端或者踢下线，之前带缓冲发送的消息会先发送
+// 不阻塞，客户端不读数据时最多等待refuseWriteTimeout之后强制关闭
+func (c *Connection) SendAndStop(msgId uint32, data []byte) error {
+	if c.IsClosed() {
+		return errors.New("Connection closed when send last msg")
+	}
+	binaryMsg, err := c.packMsg(msgId, data)
+	if err != nil {
+		c.Stop()
+		return err
+	}
+	select {
+	case c.lastMsgChan <- binaryMsg:
+	default:
+		//已经有最后一个消息在等待发送，只发送第一个
+	}
+	time.AfterFunc(2*refuseWriteTimeout, func() {
+		if !c.IsClosed() {
+			c.Stop()
+		}
+	})
+	return nil
+}
+
+// 发送可以丢弃的消息(例如移动广播)，发送队列满时丢弃最旧的消息
+func (c *Connection) SendDroppableMsg(msgId uint32, data []byte) error {
+	if c.IsClosed() {
+		return errors.New("Connection closed when send droppable msg")
+	}
+	binaryMsg, err := c.packMsg(msgId, data)
+	if err != nil {
+		return err
+	}
+	dropOldestSend(c.msgDropChan, binaryMsg)
 	return nil
 }
 
+// 向有缓冲管道发送数据，管道满时丢弃最旧的数据，不会阻塞
+func dropOldestSend(ch chan []byte, data []byte) {
+	for {
+		select {
+		case ch <- data:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
 // 设置链接属性
 func (c *Connection) Setproperty(key string, value interface{}) {
 	c.propertyLock.Lock()
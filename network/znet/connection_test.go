@@ -0,0 +1,212 @@
+package znet
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"testing"
+	"time"
+)
+
+// 创建一个没有启动读写goroutine的链接，发送队列只进不出
+func newTestConnection(t *testing.T, chanLen uint32, policy string) *Connection {
+	c, _ := newTestConnPair(t, chanLen, policy)
+	return c
+}
+
+// 创建一个链接和它的客户端一侧，测试结束时先关闭链接并等待goWait启动的goroutine退出，再恢复配置
+func newTestConnPair(t *testing.T, chanLen uint32, policy string) (*Connection, net.Conn) {
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.MaxMsgChanLen = chanLen
+		g.SendOverflowPolicy = policy
+	})
+
+	serverSide, clientSide := net.Pipe()
+	c := NewConnection(NewServer(), serverSide, 1, NewMsgHandle())
+	t.Cleanup(func() {
+		clientSide.Close()
+		c.Stop()
+		c.wg.Wait()
+	})
+	return c, clientSide
+}
+
+// 链接关闭之后发送返回错误，不会panic也不会阻塞
+func TestConnectionSendAfterStop(t *testing.T) {
+	c := newTestConnection(t, 1, OverflowBlock)
+	c.Stop()
+
+	if err := c.SendMsg(1, []byte("zinx")); err == nil {
+		t.Error("SendMsg after stop should fail")
+	}
+	if err := c.SendBuffMsg(1, []byte("zinx")); err == nil {
+		t.Error("SendBuffMsg after stop should fail")
+	}
+	if err := c.SendDroppableMsg(1, []byte("zinx")); err == nil {
+		t.Error("SendDroppableMsg after stop should fail")
+	}
+}
+
+// 可丢弃的消息在队列满时丢弃最旧的
+func TestConnectionSendDroppable(t *testing.T) {
+	c := newTestConnection(t, 2, OverflowDisconnect)
+	for i := byte(1); i <= 3; i++ {
+		if err := c.SendDroppableMsg(1, []byte{i}); err != nil {
+			t.Fatal("SendDroppableMsg err:", err)
+		}
+	}
+	if len(c.msgDropChan) != 2 {
+		t.Fatalf("drop chan len = %d, want 2", len(c.msgDropChan))
+	}
+	first := <-c.msgDropChan
+	if first[len(first)-1] != 2 {
+		t.Errorf("oldest msg not dropped, first data = %d", first[len(first)-1])
+	}
+	if c.IsClosed() {
+		t.Error("droppable overflow should not close connection")
+	}
+}
+
+// 可靠消息在队列满时断开链接
+func TestConnectionSendBuffDisconnect(t *testing.T) {
+	c := newTestConnection(t, 1, OverflowDisconnect)
+	if err := c.SendBuffMsg(1, []byte("zinx")); err != nil {
+		t.Fatal("SendBuffMsg err:", err)
+	}
+	if err := c.SendBuffMsg(1, []byte("zinx")); err == nil {
+		t.Error("SendBuffMsg on full queue should fail")
+	}
+	deadline := time.Now().Add(time.Second)
+	for !c.IsClosed() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !c.IsClosed() {
+		t.Error("connection should be closed after send buff overflow")
+	}
+}
+
+// 超过限流的消息被丢弃，warn给客户端发送警告，disconnect违规多次之后断开链接
+func TestConnectionRateLimit(t *testing.T) {
+	c := newTestConnection(t, 4, OverflowDisconnect)
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.MsgRateLimits = map[uint32]*utils.RateLimitConfig{
+			2: {Rate: 0.001, Burst: 2, Action: RateLimitWarn},
+			4: {Rate: 0.001, Burst: 1, Action: RateLimitDisconnect, MaxViolations: 2},
+		}
+	})
+
+	for i := 0; i < 2; i++ {
+		if !c.checkRateLimit(2) {
+			t.Fatal("msg within burst should pass")
+		}
+	}
+	if c.checkRateLimit(2) {
+		t.Error("msg over burst should be dropped")
+	}
+	if len(c.msgDropChan) != 1 {
+		t.Errorf("rate limit warn count = %d, want 1", len(c.msgDropChan))
+	}
+	//没有配置限流的msgID不受影响
+	if !c.checkRateLimit(3) {
+		t.Error("msg without rate limit should pass")
+	}
+
+	c.checkRateLimit(4)
+	c.checkRateLimit(4)
+	if c.IsClosed() {
+		t.Error("connection closed before MaxViolations")
+	}
+	c.checkRateLimit(4)
+	if !c.IsClosed() {
+		t.Error("connection should be closed after MaxViolations")
+	}
+}
+
+// 抓包记录收发的消息，写入之前去掉敏感字段，停止之后不再记录
+func TestConnectionCapture(t *testing.T) {
+	c := newTestConnection(t, 4, OverflowDisconnect)
+	c.TcpServer.SetCaptureRedactor(func(dir string, msgID uint32, data []byte) []byte {
+		if dir == CaptureIn && msgID == 8 {
+			return []byte("redacted")
+		}
+		return data
+	})
+	path := filepath.Join(t.TempDir(), "capture", "conn.jsonl")
+	if err := c.StartCapture(path); err != nil {
+		t.Fatal("StartCapture err:", err)
+	}
+	c.record(CaptureIn, false, 2, 7, []byte("talk"))
+	c.record(CaptureIn, false, 8, 8, []byte("password"))
+	if err := c.SendSeqMsg(7, 5, []byte("resp")); err != nil {
+		t.Fatal("SendSeqMsg err:", err)
+	}
+	c.StopCapture()
+	c.SendBuffMsg(200, []byte("after stop"))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("capture file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := ReadCapture(f)
+	if err != nil {
+		t.Fatal("ReadCapture err:", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3", len(records))
+	}
+	want := []CaptureRecord{
+		{Dir: CaptureIn, ConnID: 1, MsgID: 2, Seq: 7, Data: []byte("talk")},
+		{Dir: CaptureIn, ConnID: 1, MsgID: 8, Seq: 8, Data: []byte("redacted")},
+		{Dir: CaptureOut, ConnID: 1, MsgID: 5, Data: []byte("resp")},
+	}
+	for i, r := range records {
+		w := want[i]
+		if r.Dir != w.Dir || r.ConnID != w.ConnID || r.MsgID != w.MsgID || string(r.Data) != string(w.Data) || r.Time.IsZero() {
+			t.Errorf("record %d = %+v, want %+v", i, r, w)
+		}
+	}
+	if records[0].Seq != 7 {
+		t.Errorf("inbound seq = %d, want 7", records[0].Seq)
+	}
+}
+
+// SendAndStop先发送队列中的消息，再发送最后一个消息，然后关闭链接
+func TestConnectionSendAndStop(t *testing.T) {
+	c, clientSide := newTestConnPair(t, 4, utils.Global().SendOverflowPolicy)
+	c.goWait(c.StartWriter)
+
+	c.SendBuffMsg(1, []byte("first"))
+	if err := c.SendAndStop(RefuseMsgID, []byte("last")); err != nil {
+		t.Fatal("SendAndStop err:", err)
+	}
+	dp := NewDataPack()
+	clientSide.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for _, want := range []string{"first", "last"} {
+		msg, err := dp.Unpack(clientSide)
+		if err != nil {
+			t.Fatal("unpack err:", err)
+		}
+		if string(msg.GetData()) != want {
+			t.Errorf("data = %s, want %s", msg.GetData(), want)
+		}
+	}
+	if _, err := dp.Unpack(clientSide); err == nil {
+		t.Error("connection should be closed after last msg")
+	}
+	if !c.IsClosed() {
+		t.Error("connection should be stopped")
+	}
+	if err := c.SendAndStop(RefuseMsgID, nil); err == nil {
+		t.Error("SendAndStop after stop should fail")
+	}
+}
@@ -2,8 +2,8 @@ package znet
 
 import (
 	"errors"
-	"fmt"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
 	"sync"
 )
 
@@ -29,7 +29,7 @@ func (connMgr *ConnManager) Add(conn ziface.IConnection) {
 
 	//将conn加入到ConnManager中
 	connMgr.connections[conn.GetConnId()] = conn
-	fmt.Println("connID= ", conn.GetConnId(), " add to ConnManager successfully : conn num =", connMgr.Len())
+	zlog.Debug("add to ConnManager", "connID", conn.GetConnId(), "connNum", len(connMgr.connections))
 }
 
 // 删除链接
@@ -39,7 +39,7 @@ func (connMgr *ConnManager) Remove(conn ziface.IConnection) {
 	defer connMgr.connLock.Unlock()
 	//删除链接消息
 	delete(connMgr.connections, conn.GetConnId())
-	fmt.Println("connID= ", conn.GetConnId(), " remove from ConnManager successfully : conn num =", connMgr.Len())
+	zlog.Debug("remove from ConnManager", "connID", conn.GetConnId(), "connNum", len(connMgr.connections))
 }
 
 // 根据connID获取链接
@@ -58,22 +58,41 @@ func (connMgr *ConnManager) Get(connID uint32) (ziface.IConnection, error) {
 
 // 得到当前连接总数
 func (connMgr *ConnManager) Len() int {
+	connMgr.connLock.RLock()
+	defer connMgr.connLock.RUnlock()
 	return len(connMgr.connections)
 }
 
+// 遍历当前所有链接
+func (connMgr *ConnManager) Range(fn func(conn ziface.IConnection)) {
+	for _, conn := range connMgr.snapshot() {
+		fn(conn)
+	}
+}
+
+// 拷贝一份链接集合，conn.Stop()中会调用Remove加写锁，不能在持有锁的时候处理链接
+func (connMgr *ConnManager) snapshot() []ziface.IConnection {
+	connMgr.connLock.RLock()
+	defer connMgr.connLock.RUnlock()
+	conns := make([]ziface.IConnection, 0, len(connMgr.connections))
+	for _, conn := range connMgr.connections {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
 // 清除并终止所有链接
 func (connMgr *ConnManager) ClearConn() {
+	//停止conn的工作，Stop中会把conn从集合中删除
+	for _, conn := range connMgr.snapshot() {
+		conn.Stop()
+	}
+
 	//保护共享资源map，加写锁
 	connMgr.connLock.Lock()
 	defer connMgr.connLock.Unlock()
-
-	//删除conn并停止conn的工作
-	for connID, conn := range connMgr.connections {
-		//停止
-		conn.Stop()
-		//删除
+	for connID := range connMgr.connections {
 		delete(connMgr.connections, connID)
-
 	}
-	fmt.Println("Clear all connections succ! conn num=", connMgr.Len())
+	zlog.Info("clear all connections", "connNum", len(connMgr.connections))
 }
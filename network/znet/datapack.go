@@ -4,17 +4,40 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"io"
 	"server-1.1.0/network/utils"
 	"server-1.1.0/network/ziface"
 )
 
-// 封包，拆包的具体模块
+// 封包，拆包的具体模块   | datalen | msgID | data |  包头固定8字节
 type DataPack struct {
+	//包头的字节序
+	order binary.ByteOrder
 }
 
-// 拆包封包的实例的一个初始化方法
+// 拆包封包的实例的一个初始化方法，默认小端字节序
 func NewDataPack() *DataPack {
-	return &DataPack{}
+	return &DataPack{order: binary.LittleEndian}
+}
+
+// 大端字节序的封包拆包，和Unity客户端网络库的包头格式一致
+func NewBigEndianDataPack() *DataPack {
+	return &DataPack{order: binary.BigEndian}
+}
+
+// 根据配置中的名字创建封包拆包模块
+func NewDataPackByName(name string) (ziface.IDataPack, error) {
+	switch name {
+	case "", "default", "littleendian":
+		return NewDataPack(), nil
+	case "bigendian":
+		return NewBigEndianDataPack(), nil
+	case "varint":
+		return NewVarintDataPack(), nil
+	case "flag":
+		return NewFlagDataPack(), nil
+	}
+	return nil, errors.New("unknown packet codec " + name)
 }
 
 // 获取包头长度方法
@@ -26,18 +49,22 @@ func (dp *DataPack) GetHeadLen() uint32 {
 
 // 封包方法   | datalen | msgID | data |
 func (dp *DataPack) Pack(msg ziface.IMessage) ([]byte, error) {
+	//包头没有标志位，无法携带flags
+	if msg.GetFlags() != 0 {
+		return nil, errors.New("datapack not support msg flags")
+	}
 	//创建一个存放bytes字节的缓冲
 	dataBuff := bytes.NewBuffer([]byte{})
 	//将datalen写入databuff中  binary.Write二进制写入
-	if err := binary.Write(dataBuff, binary.LittleEndian, msg.GetDataLen()); err != nil {
+	if err := binary.Write(dataBuff, dp.order, msg.GetDataLen()); err != nil {
 		return nil, err
 	}
 	//将MsgId写入databuff中
-	if err := binary.Write(dataBuff, binary.LittleEndian, msg.GetMsgId()); err != nil {
+	if err := binary.Write(dataBuff, dp.order, msg.GetMsgId()); err != nil {
 		return nil, err
 	}
 	//将data数据写入databuff中
-	if err := binary.Write(dataBuff, binary.LittleEndian, msg.GetData()); err != nil {
+	if err := binary.Write(dataBuff, dp.order, msg.GetData()); err != nil {
 		return nil, err
 	}
 
@@ -45,26 +72,37 @@ func (dp *DataPack) Pack(msg ziface.IMessage) ([]byte, error) {
 
 }
 
-// 拆包方法（将包的Head信息读出来）之后再根据head信息里面的data长度，再一次读
-func (dp *DataPack) Unpack(binaryData []byte) (ziface.IMessage, error) {
-	//创建一个从输入二进制数据的ioReader
-	dataBuff := bytes.NewBuffer(binaryData)
+// 拆包方法（先将包的Head信息读出来）之后再根据head信息里面的data长度，再一次读
+func (dp *DataPack) Unpack(r io.Reader) (ziface.IMessage, error) {
+	//读取Msg Head二进制流8字节
+	headData := make([]byte, dp.GetHeadLen())
+	if _, err := io.ReadFull(r, headData); err != nil {
+		return nil, err
+	}
 
 	//只解压head信息，得到datalen和MsgID
-	msg := &Message{}
-
-	//读datalen
-	if err := binary.Read(dataBuff, binary.LittleEndian, &msg.DataLen); err != nil {
-		return nil, err
+	msg := &Message{
+		DataLen: dp.order.Uint32(headData[0:4]),
+		Id:      dp.order.Uint32(headData[4:8]),
 	}
-	//读MsgId
-	if err := binary.Read(dataBuff, binary.LittleEndian, &msg.Id); err != nil {
+	if err := readMsgData(r, msg); err != nil {
 		return nil, err
 	}
-	//判断datalen是否超过我们允许的最大包长度
-	if utils.GlobalObject.MaxPacketSize > 0 && msg.DataLen > utils.GlobalObject.MaxPacketSize {
-		return nil, errors.New("too Large msg data recv!")
-	}
 	return msg, nil
 
 }
+
+// 根据包头中的datalen读取数据部分，所有的封包格式共用
+func readMsgData(r io.Reader, msg *Message) error {
+	//判断datalen是否超过我们允许的最大包长度
+	if utils.Global().MaxPacketSize > 0 && msg.DataLen > utils.Global().MaxPacketSize {
+		return errors.New("too Large msg data recv!")
+	}
+	if msg.DataLen > 0 {
+		msg.Data = make([]byte, msg.DataLen)
+		if _, err := io.ReadFull(r, msg.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package znet
+
+import (
+	"encoding/binary"
+	"io"
+	"server-1.1.0/network/ziface"
+)
+
+// 带标志位的封包拆包   | datalen | msgID | flags | [seq] | data |  小端字节序
+// 包头固定部分9字节，flags带有MsgFlagSeq时后面再跟4字节请求序号
+type FlagDataPack struct{}
+
+// 包头固定部分的长度
+const flagHeadLen = 9
+
+func NewFlagDataPack() *FlagDataPack {
+	return &FlagDataPack{}
+}
+
+// 获取包头长度方法，返回包头的最大长度
+func (dp *FlagDataPack) GetHeadLen() uint32 {
+	//datalen uint32(4字节）+ID uint32（4字节）+flags uint8(1字节)+seq uint32(4字节)
+	return flagHeadLen + 4
+}
+
+// 包头带有标志位，可以携带压缩等标志
+func (dp *FlagDataPack) SupportFlags() bool {
+	return true
+}
+
+// 封包方法
+func (dp *FlagDataPack) Pack(msg ziface.IMessage) ([]byte, error) {
+	buf := make([]byte, flagHeadLen, int(dp.GetHeadLen())+len(msg.GetData()))
+	binary.LittleEndian.PutUint32(buf[0:4], msg.GetDataLen())
+	binary.LittleEndian.PutUint32(buf[4:8], msg.GetMsgId())
+	buf[8] = msg.GetFlags()
+	if msg.GetFlags()&MsgFlagSeq != 0 {
+		buf = binary.LittleEndian.AppendUint32(buf, msg.GetSeq())
+	}
+	buf = append(buf, msg.GetData()...)
+	return buf, nil
+}
+
+// 拆包方法
+func (dp *FlagDataPack) Unpack(r io.Reader) (ziface.IMessage, error) {
+	headData := make([]byte, flagHeadLen)
+	if _, err := io.ReadFull(r, headData); err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		DataLen: binary.LittleEndian.Uint32(headData[0:4]),
+		Id:      binary.LittleEndian.Uint32(headData[4:8]),
+		Flags:   headData[8],
+	}
+	if msg.Flags&MsgFlagSeq != 0 {
+		seqData := make([]byte, 4)
+		if _, err := io.ReadFull(r, seqData); err != nil {
+			return nil, err
+		}
+		msg.Seq = binary.LittleEndian.Uint32(seqData)
+	}
+	if err := readMsgData(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -1,78 +1,67 @@
 package znet
 
 import (
-	"fmt"
-	"io"
+	"bytes"
 	"net"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
 	"testing"
 	"time"
 )
 
-// 只是负责datapack拆包 封包的单元测试
+// 参与测试的所有封包格式
+var testDataPacks = map[string]ziface.IDataPack{
+	"default":   NewDataPack(),
+	"bigendian": NewBigEndianDataPack(),
+	"varint":    NewVarintDataPack(),
+	"flag":      NewFlagDataPack(),
+}
+
+// 只是负责datapack拆包 封包的单元测试，每种封包格式都跑一遍
 func TestDataPack(t *testing.T) {
+	for name, dp := range testDataPacks {
+		t.Run(name, func(t *testing.T) {
+			testDataPackRoundTrip(t, dp)
+		})
+	}
+}
+
+func testDataPackRoundTrip(t *testing.T, dp ziface.IDataPack) {
 	//模拟服务器
 	//1 创建socket TCP Server
-	listener, err := net.Listen("tcp", "127.0.0.1:7777")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
-		fmt.Println("server listen err:", err)
-		return
+		t.Fatal("server listen err:", err)
 	}
+	defer listener.Close()
+
+	recvChan := make(chan ziface.IMessage, 2)
+	errChan := make(chan error, 1)
 	//创建一个go 承载负责从客户端处理业务
 	go func() {
-		//从客户端读取数据，拆包处理
-		for {
-			conn, err := listener.Accept()
+		conn, err := listener.Accept()
+		if err != nil {
+			errChan <- err
+			return
+		}
+		defer conn.Close()
+		//处理客户端请求，拆包过程
+		for i := 0; i < 2; i++ {
+			msg, err := dp.Unpack(conn)
 			if err != nil {
-				fmt.Println("server accept error", err)
+				errChan <- err
+				return
 			}
-			go func(conn net.Conn) {
-				//处理客户端请求
-				//拆包过程
-				//定义一个拆包的对象dp
-				dp := NewDataPack()
-				for {
-					//第一次从conn读，把包head读出来
-					headData := make([]byte, dp.GetHeadLen())
-					_, err := io.ReadFull(conn, headData)
-					if err != nil {
-						fmt.Println("read head err")
-						break
-					}
-					msgHead, err := dp.Unpack(headData)
-					if err != nil {
-						fmt.Println("server unpack err", err)
-						return
-					}
-					if msgHead.GetDataLen() > 0 {
-						//msg是有数据的需要第二次读取
-						//第二次从conn读，根据head中datalen在读取data内容
-
-						msg := msgHead.(*Message)
-						msg.Data = make([]byte, msg.GetDataLen())
-						//根据datalen的长度再次从io流中读取
-						_, err := io.ReadFull(conn, msg.Data)
-						if err != nil {
-							fmt.Println("server unpack data err :", err)
-							return
-						}
-						//完整的一个消息已经读取完毕
-						fmt.Println("--->Recv MsgID:", msg.Id, ",datalen =", msg.DataLen, "data =", string(msg.Data))
-					}
-
-				}
-
-			}(conn)
+			recvChan <- msg
 		}
 	}()
 
 	//模拟客户端
-	conn, err := net.Dial("tcp", "127.0.0.1:7777")
+	conn, err := net.Dial("tcp", listener.Addr().String())
 	if err != nil {
-		fmt.Println("client dial err:", err)
-		return
+		t.Fatal("client dial err:", err)
 	}
-	//创建一个封包对象 dp
-	dp := NewDataPack()
+	defer conn.Close()
 
 	//模拟粘包过程，封装两个msg一同发送
 	//封装第一个msg1包
@@ -83,28 +72,117 @@ func TestDataPack(t *testing.T) {
 	}
 	sendData1, err := dp.Pack(msg1)
 	if err != nil {
-		fmt.Println("client pack msg1 error", err)
-		return
+		t.Fatal("client pack msg1 error", err)
 	}
-	//封装第二个msg2包
+	//封装第二个msg2包，msgID较大，变长包头需要多个字节
 	msg2 := &Message{
-		Id:      2,
+		Id:      PongMsgID,
 		DataLen: 7,
 		Data:    []byte{'n', 'i', 'h', 'a', 'o', '!', '!'},
 	}
 	sendData2, err := dp.Pack(msg2)
 	if err != nil {
-		fmt.Println("client pack msg2 error", err)
-		return
+		t.Fatal("client pack msg2 error", err)
 	}
+	//将2个包粘在一起，一次性发送给服务端
 	sendData1 = append(sendData1, sendData2...)
-	//将2个包粘在一起
+	if _, err := conn.Write(sendData1); err != nil {
+		t.Fatal("client write error", err)
+	}
+
+	for _, want := range []*Message{msg1, msg2} {
+		select {
+		case got := <-recvChan:
+			if got.GetMsgId() != want.Id || got.GetDataLen() != want.DataLen || !bytes.Equal(got.GetData(), want.Data) {
+				t.Errorf("recv MsgID:%d datalen:%d data:%s, want MsgID:%d datalen:%d data:%s",
+					got.GetMsgId(), got.GetDataLen(), got.GetData(), want.Id, want.DataLen, want.Data)
+			}
+		case err := <-errChan:
+			t.Fatal("server unpack err:", err)
+		case <-time.After(3 * time.Second):
+			t.Fatal("recv msg timeout")
+		}
+	}
+}
 
-	//一次性发送给服务端
-	conn.Write(sendData1)
+// 超过MaxPacketSize的包在拆包时被拒绝
+func TestDataPackTooLarge(t *testing.T) {
+	for name, dp := range testDataPacks {
+		t.Run(name, func(t *testing.T) {
+			data := make([]byte, utils.Global().MaxPacketSize+1)
+			binaryMsg, err := dp.Pack(NewMsgPackage(1, data))
+			if err != nil {
+				t.Fatal("pack error", err)
+			}
+			if _, err := dp.Unpack(bytes.NewReader(binaryMsg)); err == nil {
+				t.Error("unpack too large msg should fail")
+			}
+		})
+	}
+}
 
-	time.Sleep(3 * time.Second)
-	//阻塞客户端
-	select {}
+// 只有带标志位的封包格式能携带flags
+func TestDataPackFlags(t *testing.T) {
+	msg := NewMsgPackage(1, []byte("zinx"))
+	msg.SetFlags(1)
 
+	binaryMsg, err := NewFlagDataPack().Pack(msg)
+	if err != nil {
+		t.Fatal("pack error", err)
+	}
+	got, err := NewFlagDataPack().Unpack(bytes.NewReader(binaryMsg))
+	if err != nil {
+		t.Fatal("unpack error", err)
+	}
+	if got.GetFlags() != 1 {
+		t.Errorf("flags = %d, want 1", got.GetFlags())
+	}
+
+	if _, err := NewDataPack().Pack(msg); err == nil {
+		t.Error("default datapack should reject msg flags")
+	}
+}
+
+// 带MsgFlagSeq标志位的消息在包头中携带请求序号
+func TestDataPackSeq(t *testing.T) {
+	msg := NewMsgPackage(1, []byte("zinx"))
+	msg.SetSeq(12345)
+	msg.SetFlags(MsgFlagSeq)
+
+	dp := NewFlagDataPack()
+	binaryMsg, err := dp.Pack(msg)
+	if err != nil {
+		t.Fatal("pack error", err)
+	}
+	got, err := dp.Unpack(bytes.NewReader(binaryMsg))
+	if err != nil {
+		t.Fatal("unpack error", err)
+	}
+	if got.GetSeq() != 12345 || string(got.GetData()) != "zinx" {
+		t.Errorf("seq = %d data = %s, want seq 12345 data zinx", got.GetSeq(), got.GetData())
+	}
+}
+
+// 压缩之后的数据可以还原，解压超过上限的数据被拒绝
+func TestCompressData(t *testing.T) {
+	data := bytes.Repeat([]byte("SyncPlayers"), 1000)
+	compressed, err := compressData(data)
+	if err != nil {
+		t.Fatal("compress error", err)
+	}
+	if len(compressed) >= len(data) {
+		t.Errorf("compressed len %d not less than %d", len(compressed), len(data))
+	}
+	got, err := decompressData(compressed)
+	if err != nil {
+		t.Fatal("decompress error", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("decompressed data not equal")
+	}
+
+	setTestConfig(t, func(g *utils.GlobalObj) { g.MaxDecompressSize = uint32(len(data) - 1) })
+	if _, err := decompressData(compressed); err == nil {
+		t.Error("decompress over MaxDecompressSize should fail")
+	}
 }
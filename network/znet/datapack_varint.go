@@ -0,0 +1,83 @@
+package znet
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"server-1.1.0/network/ziface"
+)
+
+// 变长包头的封包拆包   | datalen(uvarint) | msgID(uvarint) | data |
+// 小包和小的msgID只需要2字节包头，节省带宽
+type VarintDataPack struct{}
+
+func NewVarintDataPack() *VarintDataPack {
+	return &VarintDataPack{}
+}
+
+// 获取包头最大长度
+func (dp *VarintDataPack) GetHeadLen() uint32 {
+	return 2 * binary.MaxVarintLen32
+}
+
+// 封包方法
+func (dp *VarintDataPack) Pack(msg ziface.IMessage) ([]byte, error) {
+	if msg.GetFlags() != 0 {
+		return nil, errors.New("varint datapack not support msg flags")
+	}
+	buf := make([]byte, 0, int(dp.GetHeadLen())+len(msg.GetData()))
+	buf = binary.AppendUvarint(buf, uint64(msg.GetDataLen()))
+	buf = binary.AppendUvarint(buf, uint64(msg.GetMsgId()))
+	buf = append(buf, msg.GetData()...)
+	return buf, nil
+}
+
+// 拆包方法
+func (dp *VarintDataPack) Unpack(r io.Reader) (ziface.IMessage, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &byteReader{r: r}
+	}
+	dataLen, err := readUvarint32(br)
+	if err != nil {
+		return nil, err
+	}
+	msgID, err := readUvarint32(br)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{
+		Id:      msgID,
+		DataLen: dataLen,
+	}
+	if err := readMsgData(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// 读取一个不超过uint32的uvarint
+func readUvarint32(br io.ByteReader) (uint32, error) {
+	v, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, err
+	}
+	if v > 1<<32-1 {
+		return 0, errors.New("varint overflow uint32")
+	}
+	return uint32(v), nil
+}
+
+// 每次只读一个字节的ByteReader，不会多读走后面数据部分的字节
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		return 0, err
+	}
+	return b.buf[0], nil
+}
@@ -0,0 +1,71 @@
+package znet
+
+import (
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"sync/atomic"
+	"time"
+)
+
+// 记录最后一次收到客户端消息的时间
+func (c *Connection) updateActivity() {
+	atomic.StoreInt64(&c.lastActivity, time.Now().UnixNano())
+}
+
+// 获取最后一次收到客户端消息的时间
+func (c *Connection) LastActivity() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.lastActivity))
+}
+
+// 处理心跳消息，返回true表示该消息是心跳消息，已经处理完毕
+func (c *Connection) handleHeartbeat(msg ziface.IMessage) bool {
+	switch msg.GetMsgId() {
+	case PingMsgID:
+		//客户端发来的心跳，原样回复，发送队列满时丢弃，不阻塞Reader
+		if err := c.SendDroppableMsg(PongMsgID, msg.GetData()); err != nil {
+			c.logger.Warn("send pong error", "err", err)
+		}
+		return true
+	case PongMsgID:
+		//服务器发出的心跳得到回复，更新活跃时间即可
+		return true
+	}
+	return false
+}
+
+// 空闲检测的goroutine，每HeartbeatInterval检测一次，空闲时主动发送心跳，超过MaxIdleTime时断开链接
+func (c *Connection) StartHeartbeat() {
+	interval := time.Duration(utils.Global().HeartbeatInterval) * time.Second
+	maxIdle := time.Duration(utils.Global().MaxIdleTime) * time.Second
+	if interval <= 0 || interval > maxIdle {
+		interval = maxIdle
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			idle := time.Since(c.LastActivity())
+			if idle >= maxIdle {
+				c.logger.Info("conn idle timeout", "idle", idle)
+				//Stop会调用OnConnStop钩子，并关闭socket让Reader退出
+				c.Stop()
+				return
+			}
+			//空闲超过一半的间隔就发送心跳，上一次的回复稍晚于检测时不会漏发一次心跳导致超时
+			if idle >= interval/2 {
+				c.sendPing()
+			}
+		case <-c.ExitChan:
+			return
+		}
+	}
+}
+
+// 向客户端发送一个心跳请求，不阻塞空闲检测
+func (c *Connection) sendPing() {
+	if err := c.SendDroppableMsg(PingMsgID, nil); err != nil {
+		c.logger.Warn("send ping error", "err", err)
+	}
+}
@@ -0,0 +1,113 @@
+package znet
+
+import (
+	"errors"
+	"net"
+	"os"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"testing"
+	"time"
+)
+
+// 客户端的心跳原样回复，回复服务器心跳的链接一直保持，不再回复之后超过MaxIdleTime断开并调用OnConnStop
+func TestConnectionHeartbeat(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) {
+		g.MaxIdleTime = 2
+		g.HeartbeatInterval = 1
+	})
+	stopped := make(chan struct{})
+	s.SetOnConnStop(func(conn ziface.IConnection) { close(stopped) })
+
+	conn := dialTestServer(t)
+	defer conn.Close()
+	dp := NewDataPack()
+	send := func(msgID uint32, data []byte) {
+		binaryMsg, _ := dp.Pack(NewMsgPackage(msgID, data))
+		if _, err := conn.Write(binaryMsg); err != nil {
+			t.Fatal("write err:", err)
+		}
+	}
+
+	send(PingMsgID, []byte("client"))
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	msg, err := dp.Unpack(conn)
+	if err != nil {
+		t.Fatal("unpack pong err:", err)
+	}
+	if msg.GetMsgId() != PongMsgID || string(msg.GetData()) != "client" {
+		t.Errorf("msg = %d %s, want pong client", msg.GetMsgId(), msg.GetData())
+	}
+
+	//比MaxIdleTime更久，一直回复服务器的心跳
+	pings := 0
+	end := time.Now().Add(3 * time.Second)
+	for time.Now().Before(end) {
+		conn.SetReadDeadline(end)
+		msg, err := dp.Unpack(conn)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			t.Fatal("connection closed while answering ping:", err)
+		}
+		if msg.GetMsgId() == PingMsgID {
+			pings++
+			send(PongMsgID, nil)
+		}
+	}
+	if pings == 0 {
+		t.Error("server should send ping when connection is idle")
+	}
+	send(1, []byte("alive"))
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for msg.GetMsgId() != 1 {
+		if msg, err = dp.Unpack(conn); err != nil {
+			t.Fatal("echo after heartbeat err:", err)
+		}
+	}
+
+	//不再回复心跳，链接因为空闲超时被关闭
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle connection should be stopped")
+	}
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		if _, err := dp.Unpack(conn); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Error("idle connection should be closed by server")
+			}
+			break
+		}
+	}
+}
+
+// 客户端的心跳计入链接的限流，发送过快的心跳超过违规次数之后断开链接
+func TestConnectionPingRateLimit(t *testing.T) {
+	startTestServer(t, func(g *utils.GlobalObj) {
+		g.ConnRateLimit = &utils.RateLimitConfig{Rate: 1, Burst: 2, Action: RateLimitDisconnect, MaxViolations: 3}
+	})
+	conn := dialTestServer(t)
+	defer conn.Close()
+	dp := NewDataPack()
+	binaryMsg, _ := dp.Pack(NewMsgPackage(PingMsgID, nil))
+	for i := 0; i < 10; i++ {
+		if _, err := conn.Write(binaryMsg); err != nil {
+			break
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		if _, err := dp.Unpack(conn); err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				t.Error("ping flood should close the connection")
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package znet
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// IP黑白名单和每个IP的链接数限制，可以在运行时更新
+type ipFilter struct {
+	lock sync.Mutex
+	//不为空时只允许名单中的地址
+	allow []*net.IPNet
+	//禁止的地址，优先于allow
+	deny []*net.IPNet
+	//每个IP同时存在的最大链接数，0表示不限制
+	maxPerIP int
+	//每个IP当前的链接数
+	counts map[string]int
+}
+
+func newIPFilter() *ipFilter {
+	return &ipFilter{counts: make(map[string]int)}
+}
+
+// 解析CIDR列表，单独的IP按照/32(IPv6为/128)处理
+func parseCIDRs(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", s)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// 替换名单和链接数限制，已经建立的链接不受影响
+func (f *ipFilter) update(allow, deny []string, maxPerIP int) error {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return fmt.Errorf("ip allow list: %v", err)
+	}
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return fmt.Errorf("ip deny list: %v", err)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.allow = allowNets
+	f.deny = denyNets
+	f.maxPerIP = maxPerIP
+	return nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// 判断地址是否允许链接
+func (f *ipFilter) allowed(ip net.IP) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+// 占用一个IP的链接名额，超过限制时返回false
+func (f *ipFilter) acquire(ip net.IP) bool {
+	key := ip.String()
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.maxPerIP > 0 && f.counts[key] >= f.maxPerIP {
+		return false
+	}
+	f.counts[key]++
+	return true
+}
+
+// 链接断开时释放IP的链接名额
+func (f *ipFilter) release(ip net.IP) {
+	key := ip.String()
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.counts[key] <= 1 {
+		delete(f.counts, key)
+		return
+	}
+	f.counts[key]--
+}
+
+// 获取链接的对端IP，net.Pipe等没有IP的链接返回nil
+func remoteIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP
+	case nil:
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
@@ -4,6 +4,8 @@ type Message struct {
 	Id      uint32 //消息ID
 	DataLen uint32 //消息长度
 	Data    []byte //消息内容
+	Flags   uint8  //消息标志位，只有带标志位的封包格式才会携带
+	Seq     uint32 //请求序号，标志位带有MsgFlagSeq时才会携带
 }
 
 // 创建一个Msg包的方法
@@ -46,3 +48,23 @@ func (m *Message) SetData(data []byte) {
 func (m *Message) SetDataLen(datalen uint32) {
 	m.DataLen = datalen
 }
+
+// 获取消息标志位
+func (m *Message) GetFlags() uint8 {
+	return m.Flags
+}
+
+// 设置消息标志位
+func (m *Message) SetFlags(flags uint8) {
+	m.Flags = flags
+}
+
+// 获取请求序号
+func (m *Message) GetSeq() uint32 {
+	return m.Seq
+}
+
+// 设置请求序号
+func (m *Message) SetSeq(seq uint32) {
+	m.Seq = seq
+}
@@ -0,0 +1,33 @@
+package znet
+
+import (
+	"runtime/debug"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"time"
+)
+
+// 捕获业务处理中的panic，避免一个消息的错误导致整个服务器崩溃
+func Recovery() ziface.Middleware {
+	return func(request ziface.IRequest, next func()) {
+		defer func() {
+			if err := recover(); err != nil {
+				zlog.Error("recover from panic", "connID", request.GetConnection().GetConnId(),
+					"msgID", request.GetMsgID(), "err", err, "stack", string(debug.Stack()))
+			}
+		}()
+		next()
+	}
+}
+
+// 统计业务处理耗时，超过slow的消息打印日志
+func Timing(slow time.Duration) ziface.Middleware {
+	return func(request ziface.IRequest, next func()) {
+		start := time.Now()
+		next()
+		if cost := time.Since(start); cost >= slow {
+			zlog.Warn("slow handler", "connID", request.GetConnection().GetConnId(),
+				"msgID", request.GetMsgID(), "cost", cost)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package znet
+
+import (
+	"reflect"
+	"server-1.1.0/network/ziface"
+	"testing"
+)
+
+// 记录调用顺序的路由
+type recordRouter struct {
+	BaseRouter
+	calls *[]string
+}
+
+func (r *recordRouter) Handle(request ziface.IRequest) {
+	value, _ := request.Get("mw")
+	*r.calls = append(*r.calls, "handle:"+value.(string))
+}
+
+func recordMiddleware(name string, calls *[]string) ziface.Middleware {
+	return func(request ziface.IRequest, next func()) {
+		*calls = append(*calls, name)
+		request.Set("mw", name)
+		next()
+		*calls = append(*calls, name+" done")
+	}
+}
+
+// 全局中间件先于路由中间件执行，不调用next时中断处理，Recovery捕获panic
+func TestMsgHandleMiddleware(t *testing.T) {
+	var calls []string
+	mh := NewMsgHandle()
+	mh.Use(Recovery(), recordMiddleware("global", &calls))
+	mh.AddRouter(1, &recordRouter{calls: &calls}, recordMiddleware("route", &calls))
+	mh.AddRouter(2, &recordRouter{calls: &calls}, func(request ziface.IRequest, next func()) {})
+	mh.AddRouter(3, &recordRouter{calls: &calls}, func(request ziface.IRequest, next func()) {
+		panic("handler panic")
+	})
+
+	conn := newTestConnection(t, 1, OverflowDisconnect)
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(1, nil)})
+	want := []string{"global", "route", "handle:route", "route done", "global done"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(2, nil)})
+	want = []string{"global", "global done"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	//panic被Recovery捕获，不会传到测试中
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(3, nil)})
+}
@@ -1,49 +1,180 @@
 package znet
 
 import (
-	"fmt"
+	"encoding/binary"
+	"errors"
+	"runtime/debug"
 	"server-1.1.0/network/utils"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Worker消息队列满时的处理策略，在zinx.json的TaskQueueFullPolicy中配置
+const (
+	TaskQueueBlock  = "block"  //阻塞Reader等待队列有空位(默认)
+	TaskQueueReject = "reject" //拒绝消息，给客户端回复ServerBusyMsgID
+	TaskQueueSpill  = "spill"  //放入Worker的溢出队列，不阻塞Reader
 )
 
 // 消息处理模块的实现
 type MsgHandle struct {
 	//存放每个MsgID所对应的处理方法
 	Apis map[uint32]ziface.IRouter
+	//全局中间件
+	middlewares []ziface.Middleware
+	//每个MsgID自己的中间件
+	routeMiddlewares map[uint32][]ziface.Middleware
 	//负责Worker读取任务的消息队列
 	TaskQueue []chan ziface.IRequest
+	//每个Worker的溢出队列，消息队列满时使用
+	overflows []*overflowQueue
 
 	//业务工作Worker池的数量
 	WorkerPoolSize uint32
+
+	//工作池是否已经关闭，关闭之后不再接收新的消息
+	isClosed bool
+	//保护isClosed以及消息队列，保证关闭或者调整Worker数量时没有goroutine还在向TaskQueue投递消息
+	closeLock sync.RWMutex
+	//通知Worker排空消息队列之后退出
+	workerExit chan struct{}
+	//等待全部Worker退出
+	workerWait sync.WaitGroup
+	//队列满时被拒绝的消息总数
+	rejected uint64
+	//队列满时放入溢出队列的消息总数
+	spilled uint64
+}
+
+// Worker的溢出队列，不限长度，溢出队列中的消息总是比消息队列中的晚
+type overflowQueue struct {
+	lock     sync.Mutex
+	requests []ziface.IRequest
+	//有新消息放入溢出队列时通知Worker
+	notify chan struct{}
+}
+
+// 消息队列有空位并且溢出队列为空时直接放入消息队列，否则放入溢出队列，返回是否放入了溢出队列
+func (q *overflowQueue) push(taskQueue chan ziface.IRequest, request ziface.IRequest) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.requests) == 0 {
+		select {
+		case taskQueue <- request:
+			return false
+		default:
+		}
+	}
+	q.requests = append(q.requests, request)
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// 取出溢出队列中的全部消息
+func (q *overflowQueue) take() []ziface.IRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	requests := q.requests
+	q.requests = nil
+	return requests
+}
+
+// 溢出队列中的消息数量
+func (q *overflowQueue) len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.requests)
+}
+
+// RunInWorker投递的函数，和消息放在同一个Worker的队列中，由Worker依次执行
+type taskRequest struct {
+	ziface.IRequest
+	task func()
+}
+
+// 执行函数，panic时只打印日志，不影响Worker处理后面的消息
+func (r *taskRequest) run() {
+	defer func() {
+		if err := recover(); err != nil {
+			zlog.Error("worker task panic", "err", err, "stack", string(debug.Stack()))
+		}
+	}()
+	r.task()
 }
 
 // 初始化MsgHandle方法
 func NewMsgHandle() *MsgHandle {
 	return &MsgHandle{
 		Apis:           make(map[uint32]ziface.IRouter),
-		WorkerPoolSize: utils.GlobalObject.WorkerPoolSize, //从全局配置中获取
-		TaskQueue:      make([]chan ziface.IRequest, utils.GlobalObject.WorkerPoolSize),
+		WorkerPoolSize: utils.Global().WorkerPoolSize, //从全局配置中获取
+		TaskQueue:      make([]chan ziface.IRequest, utils.Global().WorkerPoolSize),
+		workerExit:     make(chan struct{}),
 	}
 }
 
 // 调度、执行对应的Router消息处理方法
 func (mh *MsgHandle) DoMsgHandler(request ziface.IRequest) {
+	//RunInWorker投递的函数，不是客户端的消息
+	if task, ok := request.(*taskRequest); ok {
+		task.run()
+		return
+	}
 	// 1 从Request中找到msgID
 	handler, ok := mh.Apis[request.GetMsgID()]
 	if !ok {
-		fmt.Println("api msgID = ", request.GetMsgID(), "is NOT FOUND! NEED Register")
+		zlog.Warn("api not found, need register", "connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID())
+		return
+	}
+
+	//统计请求数量和处理耗时
+	msgID := strconv.FormatUint(uint64(request.GetMsgID()), 10)
+	start := time.Now()
+	defer func() {
+		requestsTotal.Inc(msgID)
+		requestDuration.Observe(time.Since(start).Seconds(), msgID)
+	}()
+
+	//2 依次执行全局中间件、路由中间件，最后调度对应Router业务
+	routeMiddlewares := mh.routeMiddlewares[request.GetMsgID()]
+	chain := make([]ziface.Middleware, 0, len(mh.middlewares)+len(routeMiddlewares))
+	chain = append(chain, mh.middlewares...)
+	chain = append(chain, routeMiddlewares...)
+	runMiddlewares(request, chain, func() {
+		handler.PreHandlle(request)
+		handler.Handle(request)
+		handler.PostHandle(request)
+	})
+}
+
+// 执行中间件链，每个中间件调用next时执行下一个，全部执行完之后调用handle
+func runMiddlewares(request ziface.IRequest, chain []ziface.Middleware, handle func()) {
+	if len(chain) == 0 {
+		handle()
 		return
 	}
+	chain[0](request, func() {
+		runMiddlewares(request, chain[1:], handle)
+	})
+}
 
-	//2根据MsgId调度对应Router业务即可
-	handler.PreHandlle(request)
-	handler.Handle(request)
-	handler.PostHandle(request)
+// 添加全局中间件，需要在开始处理消息之前调用
+func (mh *MsgHandle) Use(middlewares ...ziface.Middleware) {
+	mh.middlewares = append(mh.middlewares, middlewares...)
 }
 
 // 为消息添加具体的处理逻辑
-func (mh *MsgHandle) AddRouter(msgID uint32, router ziface.IRouter) {
+func (mh *MsgHandle) AddRouter(msgID uint32, router ziface.IRouter, middlewares ...ziface.Middleware) {
+	//0 框架保留的消息ID不允许注册
+	if IsReservedMsgID(msgID) {
+		panic("reserved api,msgID=" + strconv.Itoa(int(msgID)))
+	}
 	//1 判断 当前msg绑定的API处理方法是否已经存在
 	if _, ok := mh.Apis[msgID]; ok {
 		//ID已经注册		panic是字符串拼接用strconv.Itoa()来
@@ -51,47 +182,208 @@ func (mh *MsgHandle) AddRouter(msgID uint32, router ziface.IRouter) {
 	}
 	//2 添加msg和API的绑定关系
 	mh.Apis[msgID] = router
-	fmt.Println("Add api MsgID =", msgID, "succ!")
+	if len(middlewares) > 0 {
+		if mh.routeMiddlewares == nil {
+			mh.routeMiddlewares = make(map[uint32][]ziface.Middleware)
+		}
+		mh.routeMiddlewares[msgID] = middlewares
+	}
+	zlog.Info("add api", "msgID", msgID)
 
 }
 
 // 启动一个Worker工作池(开启工作池的动作只能发生一次，一个Zinx框架只能有一个工作池
 func (mh *MsgHandle) StartWorkerPool() {
+	mh.closeLock.Lock()
+	defer mh.closeLock.Unlock()
+	mh.startWorkers()
+}
+
+// 根据WorkerPoolSize开启worker，调用时需要持有closeLock
+func (mh *MsgHandle) startWorkers() {
+	mh.TaskQueue = make([]chan ziface.IRequest, mh.WorkerPoolSize)
+	mh.overflows = make([]*overflowQueue, mh.WorkerPoolSize)
+	mh.workerExit = make(chan struct{})
 	//根据workerpoolsize分别开启worker，每个worker用一个go来承载
 	for i := 0; i < int(mh.WorkerPoolSize); i++ {
 		//当前的worker被启动
 		//1 当前的worker对应的channel消息队列 开辟空间第0个worker就用第0个channel。。。
-		mh.TaskQueue[i] = make(chan ziface.IRequest, utils.GlobalObject.MaxWorkerTaskLen)
+		mh.TaskQueue[i] = make(chan ziface.IRequest, utils.Global().MaxWorkerTaskLen)
+		mh.overflows[i] = &overflowQueue{notify: make(chan struct{}, 1)}
 		//2 启动StartOneWorker，阻塞等待消息从channel传递进来
-		go mh.StartOneWorker(i, mh.TaskQueue[i])
+		mh.workerWait.Add(1)
+		go mh.StartOneWorker(i, mh.TaskQueue[i], mh.overflows[i], mh.workerExit)
 
 	}
 
 }
 
+// 通知全部worker处理完队列中剩余的消息之后退出，并等待退出，调用时需要持有closeLock
+func (mh *MsgHandle) stopWorkers() {
+	close(mh.workerExit)
+	mh.workerWait.Wait()
+}
+
 // 启动一个Worker工作流程
-func (mh *MsgHandle) StartOneWorker(workerID int, taskQueue chan ziface.IRequest) {
-	fmt.Println("WorkerID=", workerID, "is started...")
+func (mh *MsgHandle) StartOneWorker(workerID int, taskQueue chan ziface.IRequest, overflow *overflowQueue, exit chan struct{}) {
+	zlog.Debug("worker started", "workerID", workerID)
+	defer mh.workerWait.Done()
 	//不断的阻塞等待对应消息队列的消息
 	for {
 		select {
 		//如果有消息过来，从列的就是一个客户端的Request，执行当前Request所绑定的业务
 		case request := <-taskQueue:
 			mh.DoMsgHandler(request)
+		//溢出队列有消息，按顺序处理
+		case <-overflow.notify:
+			mh.drainQueue(taskQueue, overflow)
+		//工作池关闭，把队列中剩余的消息处理完再退出
+		case <-exit:
+			mh.drainQueue(taskQueue, overflow)
+			zlog.Debug("worker stopped", "workerID", workerID)
+			return
+		}
+	}
 
+}
+
+// 按顺序处理消息队列和溢出队列中的消息，直到两个队列都为空
+func (mh *MsgHandle) drainQueue(taskQueue chan ziface.IRequest, overflow *overflowQueue) {
+	for {
+		//消息队列中的消息比溢出队列中的早，先处理
+		select {
+		case request := <-taskQueue:
+			mh.DoMsgHandler(request)
+			continue
+		default:
 		}
+		requests := overflow.take()
+		if len(requests) == 0 {
+			return
+		}
+		for _, request := range requests {
+			mh.DoMsgHandler(request)
+		}
+	}
+}
+
+// 关闭工作池，不再接收新的消息，等待所有Worker处理完队列中剩余的消息
+func (mh *MsgHandle) StopWorkerPool() {
+	mh.closeLock.Lock()
+	defer mh.closeLock.Unlock()
+	if mh.isClosed {
+		return
+	}
+	mh.isClosed = true
+	if len(mh.overflows) > 0 {
+		mh.stopWorkers()
+	}
+}
+
+// 运行时调整Worker数量，先让旧的Worker处理完已经收到的消息，再按新的数量开启Worker
+// 切换期间Reader投递消息会等待，同一个key的消息不会乱序
+func (mh *MsgHandle) ResizeWorkerPool(size uint32) error {
+	if size == 0 {
+		return errors.New("worker pool size must be greater than 0")
+	}
+	mh.closeLock.Lock()
+	defer mh.closeLock.Unlock()
+	if mh.isClosed {
+		return errors.New("worker pool is closed")
+	}
+	if len(mh.overflows) == 0 {
+		return errors.New("worker pool is not started")
+	}
+	if size == mh.WorkerPoolSize {
+		return nil
 	}
+	zlog.Info("worker pool resize", "from", mh.WorkerPoolSize, "to", size)
+	mh.stopWorkers()
+	mh.WorkerPoolSize = size
+	mh.startWorkers()
+	return nil
+}
+
+// 获取工作池的统计数据
+func (mh *MsgHandle) GetWorkerPoolStats() ziface.WorkerPoolStats {
+	mh.closeLock.RLock()
+	defer mh.closeLock.RUnlock()
+	stats := ziface.WorkerPoolStats{
+		WorkerPoolSize: mh.WorkerPoolSize,
+		QueueLens:      make([]int, len(mh.TaskQueue)),
+		OverflowLens:   make([]int, len(mh.overflows)),
+		Rejected:       atomic.LoadUint64(&mh.rejected),
+		Spilled:        atomic.LoadUint64(&mh.spilled),
+	}
+	for i, taskQueue := range mh.TaskQueue {
+		stats.QueueLens[i] = len(taskQueue)
+	}
+	for i, overflow := range mh.overflows {
+		stats.OverflowLens[i] = overflow.len()
+	}
+	return stats
+}
 
+// 在key对应的Worker中执行task，用于在其他goroutine中修改只由某个Worker处理的数据(例如玩家)
+// 和分配key相同的链接的消息不会同时执行，阻塞策略下和之后收到的消息不保证先后顺序
+// 不会阻塞，队列满时放入溢出队列，工作池没有开启或者已经关闭时直接在当前goroutine中执行
+func (mh *MsgHandle) RunInWorker(key uint32, task func()) {
+	//Worker中也会调用，关闭或者调整工作池时持有写锁并等待Worker处理完，这里不能等待读锁
+	if !mh.closeLock.TryRLock() {
+		go func() {
+			mh.closeLock.RLock()
+			mh.runInWorkerLocked(key, task)
+		}()
+		return
+	}
+	mh.runInWorkerLocked(key, task)
+}
+
+// 投递task，调用时持有closeLock的读锁，返回之前释放
+func (mh *MsgHandle) runInWorkerLocked(key uint32, task func()) {
+	if mh.isClosed || len(mh.overflows) == 0 {
+		mh.closeLock.RUnlock()
+		task()
+		return
+	}
+	workerID := key % mh.WorkerPoolSize
+	mh.overflows[workerID].push(mh.TaskQueue[workerID], &taskRequest{task: task})
+	mh.closeLock.RUnlock()
 }
 
 // 将消息交给TaskQueue,由Worker进行处理
 func (mh *MsgHandle) SendMsgToTaskQueue(request ziface.IRequest) {
-	//将消息平均分配给不通过的worker
-	//根据客户端建立的ConnID来进行分配
-	//基本的平均分配轮询法则
-	workerID := request.GetConnection().GetConnId() % mh.WorkerPoolSize
-	fmt.Println("Add ConnID =", request.GetConnection().GetConnId(), "request MsgID =", request.GetMsgID(), "to WorkID =", workerID)
-	//将消息发送给对应的worker的TaskQueue即可
-	mh.TaskQueue[workerID] <- request
+	mh.closeLock.RLock()
+	defer mh.closeLock.RUnlock()
+	//工作池已经关闭，丢弃新的消息
+	if mh.isClosed {
+		zlog.Warn("worker pool is closed, drop request", "connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID())
+		return
+	}
+	//根据链接的分配key来分配worker，默认是ConnID，设置为玩家ID之后重连的玩家仍然由同一个worker处理
+	workerID := request.GetConnection().GetDispatchKey() % mh.WorkerPoolSize
+	taskQueue := mh.TaskQueue[workerID]
+
+	switch utils.Global().TaskQueueFullPolicy {
+	case TaskQueueSpill:
+		if mh.overflows[workerID].push(taskQueue, request) {
+			atomic.AddUint64(&mh.spilled, 1)
+		}
+	case TaskQueueReject:
+		select {
+		case taskQueue <- request:
+		default:
+			atomic.AddUint64(&mh.rejected, 1)
+			//告知客户端服务器繁忙，回复带上请求序号
+			data := make([]byte, 4)
+			binary.LittleEndian.PutUint32(data, request.GetMsgID())
+			if err := request.Reply(ServerBusyMsgID, data); err != nil {
+				zlog.Warn("reply server busy error", "connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID(), "err", err)
+			}
+		}
+	default:
+		//将消息发送给对应的worker的TaskQueue即可
+		taskQueue <- request
+	}
 
 }
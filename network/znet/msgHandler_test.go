@@ -0,0 +1,94 @@
+package znet
+
+import (
+	"reflect"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"testing"
+)
+
+// 记录处理顺序的路由，第一个消息等待gate关闭之后才处理完
+type orderRouter struct {
+	BaseRouter
+	gate  chan struct{}
+	order []byte
+}
+
+func (r *orderRouter) Handle(request ziface.IRequest) {
+	if len(r.order) == 0 {
+		<-r.gate
+	}
+	r.order = append(r.order, request.GetData()...)
+}
+
+// 消息队列满时放入溢出队列，调整Worker数量之后已经收到的消息按顺序处理完毕
+func TestMsgHandleSpillAndResize(t *testing.T) {
+	conn := newTestConnection(t, 1, OverflowDisconnect)
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.WorkerPoolSize = 1
+		g.MaxWorkerTaskLen = 1
+		g.TaskQueueFullPolicy = TaskQueueSpill
+	})
+
+	router := &orderRouter{gate: make(chan struct{})}
+	mh := NewMsgHandle()
+	mh.AddRouter(1, router)
+	mh.StartWorkerPool()
+
+	for i := byte(1); i <= 5; i++ {
+		mh.SendMsgToTaskQueue(&Request{conn: conn, msg: NewMsgPackage(1, []byte{i})})
+	}
+	if stats := mh.GetWorkerPoolStats(); stats.Spilled == 0 {
+		t.Errorf("stats = %+v, want spilled msgs", stats)
+	}
+
+	close(router.gate)
+	if err := mh.ResizeWorkerPool(2); err != nil {
+		t.Fatal("resize err:", err)
+	}
+	if want := []byte{1, 2, 3, 4, 5}; !reflect.DeepEqual(router.order, want) {
+		t.Errorf("order = %v, want %v", router.order, want)
+	}
+	if stats := mh.GetWorkerPoolStats(); stats.WorkerPoolSize != 2 || len(stats.QueueLens) != 2 {
+		t.Errorf("stats after resize = %+v", stats)
+	}
+	mh.StopWorkerPool()
+}
+
+// RunInWorker投递的函数在key对应的Worker中依次执行，不和正在处理的消息同时执行
+// panic不影响后面的函数，工作池没有开启或者关闭之后直接执行
+func TestMsgHandleRunInWorker(t *testing.T) {
+	conn := newTestConnection(t, 1, OverflowDisconnect)
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.WorkerPoolSize = 2
+		g.MaxWorkerTaskLen = 1
+	})
+
+	router := &orderRouter{gate: make(chan struct{})}
+	mh := NewMsgHandle()
+	mh.AddRouter(1, router)
+	ran := false
+	mh.RunInWorker(conn.GetDispatchKey(), func() { ran = true })
+	if !ran {
+		t.Error("task should run inline before worker pool started")
+	}
+	mh.StartWorkerPool()
+
+	mh.SendMsgToTaskQueue(&Request{conn: conn, msg: NewMsgPackage(1, []byte{1})})
+	for i := byte(2); i <= 4; i++ {
+		i := i
+		mh.RunInWorker(conn.GetDispatchKey(), func() { router.order = append(router.order, i) })
+		mh.RunInWorker(conn.GetDispatchKey(), func() { panic("task panic") })
+	}
+	close(router.gate)
+	mh.StopWorkerPool()
+	if want := []byte{1, 2, 3, 4}; !reflect.DeepEqual(router.order, want) {
+		t.Errorf("order = %v, want %v", router.order, want)
+	}
+
+	ran = false
+	mh.RunInWorker(conn.GetDispatchKey(), func() { ran = true })
+	if !ran {
+		t.Error("task should run inline after worker pool stopped")
+	}
+}
@@ -0,0 +1,24 @@
+package znet
+
+// 框架内部保留的消息ID，由znet直接处理，不会交给业务路由
+const (
+	PingMsgID uint32 = 99990 //心跳请求，收到之后回复PongMsgID
+	PongMsgID uint32 = 99991 //心跳回复
+
+	RateLimitMsgID  uint32 = 99992 //限流警告，数据为被限流的msgID(uint32小端)
+	ServerBusyMsgID uint32 = 99993 //Worker消息队列已满，请求被拒绝，数据为被拒绝的msgID(uint32小端)
+	RefuseMsgID     uint32 = 99994 //拒绝链接，发送之后服务器关闭链接，数据为拒绝原因(uint32小端)
+
+	UdpTokenMsgID uint32 = 99995 //通过TCP下发UDP通道的token，数据为token(uint64小端)和UDP端口(uint16小端)
+	UdpHelloMsgID uint32 = 99996 //通过UDP发送，用于绑定客户端地址和确认UDP可用，服务器原样回复
+)
+
+// 判断消息ID是否为框架保留的ID
+func IsReservedMsgID(msgID uint32) bool {
+	switch msgID {
+	case PingMsgID, PongMsgID, RateLimitMsgID, ServerBusyMsgID, RefuseMsgID,
+		UdpTokenMsgID, UdpHelloMsgID:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package znet
+
+import (
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// protobuf消息类型约束，PT是*T并且实现了proto.Message
+type ProtoMessage[T any] interface {
+	*T
+	proto.Message
+}
+
+// 自动解析protobuf消息的路由，Handler中直接拿到解析好的消息
+type ProtoRouter[T any, PT ProtoMessage[T]] struct {
+	BaseRouter
+	//消息解析成功之后调用
+	Handler func(request ziface.IRequest, msg PT)
+	//消息解析失败时调用，可以为nil，例如给客户端回复错误码
+	OnDecodeError func(request ziface.IRequest, err error)
+}
+
+func (r *ProtoRouter[T, PT]) Handle(request ziface.IRequest) {
+	var msg PT = new(T)
+	if err := proto.Unmarshal(request.GetData(), msg); err != nil {
+		reportDecodeError(request, msg, err)
+		if r.OnDecodeError != nil {
+			r.OnDecodeError(request, err)
+		}
+		return
+	}
+	r.Handler(request, msg)
+}
+
+// 注册一个protobuf消息的路由，消息解析失败时不会调用handle
+// 例如 znet.AddProtoRouter(s, 2, func(request ziface.IRequest, msg *pb.Talk) {...})
+func AddProtoRouter[T any, PT ProtoMessage[T]](s ziface.IServer, msgID uint32, handle func(request ziface.IRequest, msg PT), middlewares ...ziface.Middleware) {
+	s.AddRouter(msgID, &ProtoRouter[T, PT]{Handler: handle}, middlewares...)
+}
+
+// 统一打印消息解析失败的日志
+func reportDecodeError(request ziface.IRequest, msg proto.Message, err error) {
+	zlog.Warn("decode proto message error", "message", string(msg.ProtoReflect().Descriptor().FullName()),
+		"connID", request.GetConnection().GetConnId(), "msgID", request.GetMsgID(), "len", len(request.GetData()), "err", err)
+}
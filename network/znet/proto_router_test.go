@@ -0,0 +1,45 @@
+package znet
+
+import (
+	"server-1.1.0/network/ziface"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// 解析成功时handler拿到解析好的消息，解析失败时不调用handler，调用OnDecodeError
+func TestProtoRouter(t *testing.T) {
+	conn := newTestConnection(t, 1, OverflowDisconnect)
+	s := conn.TcpServer
+	var got []string
+	var decodeErrs int
+	AddProtoRouter(s, 1, func(request ziface.IRequest, msg *wrapperspb.StringValue) {
+		got = append(got, msg.GetValue())
+	})
+	s.AddRouter(2, &ProtoRouter[wrapperspb.StringValue, *wrapperspb.StringValue]{
+		Handler: func(request ziface.IRequest, msg *wrapperspb.StringValue) {
+			got = append(got, msg.GetValue())
+		},
+		OnDecodeError: func(request ziface.IRequest, err error) {
+			decodeErrs++
+		},
+	})
+
+	data, err := proto.Marshal(wrapperspb.String("zinx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mh := s.GetMsgHandler()
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(1, data)})
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(1, []byte{0xff})})
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(2, data)})
+	mh.DoMsgHandler(&Request{conn: conn, msg: NewMsgPackage(2, []byte{0xff})})
+
+	if len(got) != 2 || got[0] != "zinx" || got[1] != "zinx" {
+		t.Errorf("handled = %v, want [zinx zinx]", got)
+	}
+	if decodeErrs != 1 {
+		t.Errorf("decode errors = %d, want 1", decodeErrs)
+	}
+}
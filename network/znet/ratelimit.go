@@ -0,0 +1,144 @@
+package znet
+
+import (
+	"encoding/binary"
+	"server-1.1.0/network/utils"
+	"sync"
+	"time"
+)
+
+// 超过限流之后的处理方式，在zinx.json的RateLimit配置中设置
+const (
+	RateLimitDrop       = "drop"       //丢弃消息(默认)
+	RateLimitWarn       = "warn"       //丢弃消息并给客户端发送RateLimitMsgID警告
+	RateLimitDisconnect = "disconnect" //丢弃消息，违规MaxViolations次之后断开链接
+)
+
+// 违规次数的统计窗口，窗口内违规MaxViolations次时断开链接，超过窗口之后重新计数
+const violationWindow = time.Minute
+
+// 令牌桶，每秒补充Rate个令牌，最多存放Burst个
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// 补充令牌并判断是否还有令牌，不消耗令牌，需要的令牌都足够时再调用spend
+func (b *tokenBucket) ready(now time.Time, config *utils.RateLimitConfig) bool {
+	burst := float64(config.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if b.last.IsZero() {
+		//新的令牌桶是满的
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * config.Rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.last = now
+	return b.tokens >= 1
+}
+
+// 消耗一个令牌
+func (b *tokenBucket) spend() {
+	b.tokens--
+}
+
+// 违规次数，距离窗口内第一次违规超过violationWindow之后重新计数
+type violationCounter struct {
+	count int
+	start time.Time
+}
+
+// 记录一次违规，返回窗口内的违规次数
+func (v *violationCounter) add(now time.Time) int {
+	if now.Sub(v.start) > violationWindow {
+		v.count = 0
+		v.start = now
+	}
+	v.count++
+	return v.count
+}
+
+// 每个链接的限流器，TCP的Reader和UDP的读取goroutine共用
+type rateLimiter struct {
+	lock sync.Mutex
+	//链接所有消息合计的令牌桶
+	connBucket tokenBucket
+	//超过链接限流的违规次数，和msgID无关
+	connViolations violationCounter
+	//按msgID区分的令牌桶
+	msgBuckets map[uint32]*tokenBucket
+	//按msgID累计的违规次数
+	msgViolations map[uint32]*violationCounter
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		msgBuckets:    make(map[uint32]*tokenBucket),
+		msgViolations: make(map[uint32]*violationCounter),
+	}
+}
+
+// 检查消息是否超过限流，返回超过的限流配置和该限流窗口内的违规次数，没有超过返回nil
+// msgID和链接的令牌都足够时才同时消耗，被拒绝的消息不消耗任何令牌
+// 每次都从全局配置中读取，配置重新加载之后立即生效
+func (rl *rateLimiter) check(msgID uint32, now time.Time) (*utils.RateLimitConfig, int) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	g := utils.Global()
+
+	var msgBucket *tokenBucket
+	if config := g.MsgRateLimits[msgID]; config != nil && config.Rate > 0 {
+		msgBucket = rl.msgBuckets[msgID]
+		if msgBucket == nil {
+			msgBucket = &tokenBucket{}
+			rl.msgBuckets[msgID] = msgBucket
+		}
+		if !msgBucket.ready(now, config) {
+			violations := rl.msgViolations[msgID]
+			if violations == nil {
+				violations = &violationCounter{}
+				rl.msgViolations[msgID] = violations
+			}
+			return config, violations.add(now)
+		}
+	}
+	if config := g.ConnRateLimit; config != nil && config.Rate > 0 {
+		if !rl.connBucket.ready(now, config) {
+			return config, rl.connViolations.add(now)
+		}
+		rl.connBucket.spend()
+	}
+	if msgBucket != nil {
+		msgBucket.spend()
+	}
+	return nil, 0
+}
+
+// 入站消息限流，返回false表示消息被丢弃，不再交给工作池处理
+func (c *Connection) checkRateLimit(msgID uint32) bool {
+	config, violations := c.limiter.check(msgID, time.Now())
+	if config == nil {
+		return true
+	}
+
+	switch config.Action {
+	case RateLimitWarn:
+		//告知客户端哪个msgID被限流了
+		data := make([]byte, 4)
+		binary.LittleEndian.PutUint32(data, msgID)
+		if err := c.SendDroppableMsg(RateLimitMsgID, data); err != nil {
+			c.logger.Warn("send rate limit warn error", "msgID", msgID, "err", err)
+		}
+	case RateLimitDisconnect:
+		if violations >= config.MaxViolations {
+			c.logger.Warn("rate limit exceeded, stop connection", "msgID", msgID, "violations", violations)
+			c.Stop()
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package znet
+
+import (
+	"server-1.1.0/network/utils"
+	"testing"
+	"time"
+)
+
+// 链接限流拒绝时不消耗msgID的令牌，链接的违规次数和msgID分开统计，超过统计窗口之后重新计数
+func TestRateLimiter(t *testing.T) {
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.MsgRateLimits = map[uint32]*utils.RateLimitConfig{
+			2: {Rate: 0.001, Burst: 2, Action: RateLimitDrop},
+		}
+		g.ConnRateLimit = &utils.RateLimitConfig{Rate: 1, Burst: 1, Action: RateLimitDisconnect, MaxViolations: 10}
+	})
+	rl := newRateLimiter()
+	now := time.Now()
+
+	if config, _ := rl.check(3, now); config != nil {
+		t.Fatal("first msg should pass")
+	}
+	for i, msgID := range []uint32{2, 3, 2} {
+		config, violations := rl.check(msgID, now)
+		if config != utils.Global().ConnRateLimit {
+			t.Fatalf("msg %d should be limited by ConnRateLimit", msgID)
+		}
+		if violations != i+1 {
+			t.Errorf("conn violations = %d, want %d", violations, i+1)
+		}
+	}
+	if tokens := rl.msgBuckets[2].tokens; tokens != 2 {
+		t.Errorf("msg tokens = %v after conn limit, want 2", tokens)
+	}
+	if rl.msgViolations[2] != nil {
+		t.Error("conn limit should not count as msgID violation")
+	}
+
+	//链接的令牌补充之后msgID的令牌才被消耗
+	now = now.Add(time.Second)
+	if config, _ := rl.check(2, now); config != nil {
+		t.Fatal("msg should pass after conn tokens refilled")
+	}
+	if tokens := rl.msgBuckets[2].tokens; tokens != 1 {
+		t.Errorf("msg tokens = %v, want 1", tokens)
+	}
+
+	//超过统计窗口之后违规次数重新计数
+	now = now.Add(violationWindow + time.Second)
+	rl.check(3, now)
+	if _, violations := rl.check(3, now); violations != 1 {
+		t.Errorf("conn violations = %d after window, want 1", violations)
+	}
+}
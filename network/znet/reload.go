@@ -0,0 +1,51 @@
+package znet
+
+import (
+	"encoding/json"
+	"net/http"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+	"strings"
+)
+
+// 重新加载zinx.json，生效的配置同步到Server，例如IP黑白名单和违禁词
+// 返回已经生效的字段和需要重启才能生效的字段，失败时当前的配置不变
+func (s *Server) ReloadConfig() ([]string, []string, error) {
+	applied, restart, err := utils.ReloadConfig()
+	if err != nil {
+		zlog.Error("reload config error, keep running config", "err", err)
+		return nil, nil, err
+	}
+	g := utils.Global()
+	if err := s.UpdateIPFilter(g.IPAllowList, g.IPDenyList, g.MaxConnPerIP); err != nil {
+		zlog.Error("reload ip filter error", "err", err)
+	}
+	s.UpdateBanWord(g.BanWords)
+	zlog.Info("config reloaded", "applied", strings.Join(applied, ","), "restart", strings.Join(restart, ","))
+	return applied, restart, nil
+}
+
+// 管理接口重新加载配置的结果
+type reloadResponse struct {
+	Applied []string `json:"applied"`
+	Restart []string `json:"restart"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// POST {AdminPath}/reload 重新加载配置
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp := &reloadResponse{}
+	applied, restart, err := s.ReloadConfig()
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		resp.Error = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		resp.Applied, resp.Restart = applied, restart
+	}
+	json.NewEncoder(w).Encode(resp)
+}
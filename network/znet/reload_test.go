@@ -0,0 +1,54 @@
+package znet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"testing"
+)
+
+// 有链接正在收发消息时重新加载配置，需要go test -race检查读写配置没有数据竞争
+func TestServerReloadWhileServing(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) {})
+
+	path := filepath.Join(t.TempDir(), "zinx.json")
+	old := utils.ConfigFile
+	utils.ConfigFile = path
+	t.Cleanup(func() { utils.ConfigFile = old })
+
+	conn := dialTestServer(t)
+	defer conn.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 100; i++ {
+			msg, err := echoOverConn(conn, []byte("reload"))
+			if err != nil {
+				done <- err
+				return
+			}
+			if string(msg.GetData()) != "reload" {
+				done <- fmt.Errorf("echo data = %s, want reload", msg.GetData())
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	for i := 0; i < 20; i++ {
+		content := fmt.Sprintf(`{"MaxConn": %d, "MaxPacketSize": 4096, "BanWords": ["w%d"], "LogLevel": "info"}`, 100+i, i)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := s.ReloadConfig(); err != nil {
+			t.Fatal("reload err:", err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal("echo while reloading err:", err)
+	}
+	if g := utils.Global(); g.MaxConn != 119 || g.TcpServer != s {
+		t.Errorf("MaxConn = %d, TcpServer = %v after reload", g.MaxConn, g.TcpServer)
+	}
+}
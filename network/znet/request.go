@@ -8,6 +8,9 @@ type Request struct {
 
 	//得到客户端请求的数据
 	msg ziface.IMessage
+
+	//中间件之间传递的值，用到时才创建
+	values map[string]interface{}
 }
 
 func (r *Request) GetConnection() ziface.IConnection {
@@ -19,3 +22,26 @@ func (r *Request) GetData() []byte {
 func (r *Request) GetMsgID() uint32 {
 	return r.msg.GetMsgId()
 }
+
+func (r *Request) GetSeq() uint32 {
+	return r.msg.GetSeq()
+}
+
+// 回复本次请求，使用可靠的带缓冲发送
+func (r *Request) Reply(msgID uint32, data []byte) error {
+	return r.conn.SendSeqMsg(r.GetSeq(), msgID, data)
+}
+
+// 设置本次请求携带的值
+func (r *Request) Set(key string, value interface{}) {
+	if r.values == nil {
+		r.values = make(map[string]interface{})
+	}
+	r.values[key] = value
+}
+
+// 获取本次请求携带的值
+func (r *Request) Get(key string) (interface{}, bool) {
+	value, ok := r.values[key]
+	return value, ok
+}
@@ -1,11 +1,19 @@
 package znet
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"regexp"
 	"server-1.1.0/network/utils"
 	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"net"
 )
@@ -25,11 +33,44 @@ type Server struct {
 	//当前的server的消息管理模块，原来绑定MsgId和对应的处理业务API关系
 	MsgHandler ziface.IMsgHanle
 	//该server的连接管理器
-	ConnMgr     ziface.IConnManager
+	ConnMgr ziface.IConnManager
+	//该server的封包拆包模块
+	Packet      ziface.IDataPack
 	Lock        *sync.RWMutex
 	BanWordBase []string //配置生成
 	Wait        sync.WaitGroup
 
+	//当前监听的socket，关闭服务器时用来停止接收新的链接
+	listener net.Listener
+	//TLS配置，没有配置证书时为nil，使用明文TCP
+	tlsConfig *tls.Config
+	//WebSocket监听服务，没有开启时为nil
+	wsServer *http.Server
+	//指标监听服务，没有开启时为nil
+	metricsServer *http.Server
+	//管理接口监听服务，只监听本机地址，没有开启时为nil
+	adminServer *http.Server
+	//UDP监听和会话，没有开启时为nil
+	udp *udpManager
+	//下一个链接的ID，TCP和WebSocket共用
+	cid uint32
+	//服务器是否正在关闭
+	exitChan chan struct{}
+	//保护exitChan的关闭，关闭之后handleConn不再创建新的链接
+	exitLock sync.Mutex
+	//每个链接以及拒绝链接的goroutine，Stop时等待全部退出
+	connWg sync.WaitGroup
+	//保证Stop只执行一次
+	stopOnce sync.Once
+	//创建链接之前的准入检查
+	admitHooks []ziface.AdmitHook
+	//IP黑白名单和每个IP的链接数限制
+	ipFilter *ipFilter
+	//业务注册的管理接口，path -> handler
+	adminHandlers map[string]http.HandlerFunc
+	//抓包时去掉敏感字段
+	captureRedactor ziface.CaptureRedactor
+
 	// =======================
 	//新增两个hook函数原型
 
@@ -41,10 +82,14 @@ type Server struct {
 	// =======================
 }
 
-func (s *Server) AddRouter(msgID uint32, router ziface.IRouter) {
+func (s *Server) AddRouter(msgID uint32, router ziface.IRouter, middlewares ...ziface.Middleware) {
+
+	s.MsgHandler.AddRouter(msgID, router, middlewares...)
+}
 
-	s.MsgHandler.AddRouter(msgID, router)
-	fmt.Println("add Router Succ")
+// 添加全局中间件
+func (s *Server) Use(middlewares ...ziface.Middleware) {
+	s.MsgHandler.Use(middlewares...)
 }
 func (self *Server) IsBanWord(txt string) bool {
 	self.Lock.RLock()
@@ -52,7 +97,7 @@ func (self *Server) IsBanWord(txt string) bool {
 	for _, v := range self.BanWordBase {
 		match, _ := regexp.MatchString(v, txt)
 		if match {
-			fmt.Println("发现违禁词:", v)
+			zlog.Info("发现违禁词", "word", v)
 		}
 		if match {
 			return match
@@ -67,67 +112,180 @@ func (self *Server) UpdateBanWord(banWord []string) {
 }
 
 // 启动网络服务
-func (s *Server) Start() {
-	fmt.Printf("[Start] server name : %s,listener at Ip: %s ,Port:%d is starting\n",
-		utils.GlobalObject.Name, utils.GlobalObject.Host, utils.GlobalObject.TcpPort)
-	fmt.Printf("[Zinx] version %s, MaxConn:%d ,MaxPacketSize:%d\n",
-		utils.GlobalObject.Version, utils.GlobalObject.MaxConn, utils.GlobalObject.MaxPacketSize)
+func (s *Server) Start() error {
+	zlog.Info("server is starting", "name", utils.Global().Name,
+		"host", utils.Global().Host, "port", utils.Global().TcpPort)
+	zlog.Info("zinx config", "version", utils.Global().Version,
+		"maxConn", utils.Global().MaxConn, "maxPacketSize", utils.Global().MaxPacketSize)
 
-	go func() {
-		//0 开启开启消息队列及工作池
-		s.MsgHandler.StartWorkerPool()
-		//1 获取TCP的addr
-		addr, err := net.ResolveTCPAddr(s.IPVersion, fmt.Sprintf("%s:%d", s.IP, s.Port))
-		if err != nil {
-			fmt.Println("resolve tcp addr err", err)
-			return
-		}
-		//2 监听服务器地址
-		lisenner, err := net.ListenTCP(s.IPVersion, addr)
-		if err != nil {
-			fmt.Println("listen", s.IPVersion, "err", err)
-			return
+	//加载TLS证书，TCP和WebSocket共用
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		return fmt.Errorf("load tls config: %w", err)
+	}
+	s.tlsConfig = tlsConfig
+	//加载IP黑白名单
+	g := utils.Global()
+	if err := s.UpdateIPFilter(g.IPAllowList, g.IPDenyList, g.MaxConnPerIP); err != nil {
+		return fmt.Errorf("load ip filter: %w", err)
+	}
+	s.UpdateBanWord(g.BanWords)
+
+	//1 监听服务器地址，在开启其他功能之前监听，失败时直接返回
+	//listener在Start返回之前设置，Stop一定能关闭
+	addr, err := net.ResolveTCPAddr(s.IPVersion, fmt.Sprintf("%s:%d", s.IP, s.Port))
+	if err != nil {
+		return fmt.Errorf("resolve tcp addr: %w", err)
+	}
+	tcpListener, err := net.ListenTCP(s.IPVersion, addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", s.IPVersion, err)
+	}
+	var lisenner net.Listener = tcpListener
+	if s.tlsConfig != nil {
+		//配置了证书，在TCP之上进行TLS握手，Connection的读写不受影响
+		lisenner = tls.NewListener(tcpListener, s.tlsConfig)
+	}
+	//开启UDP监听，用于移动等可以丢弃的消息
+	if utils.Global().UdpPort > 0 {
+		if err := s.startUdp(); err != nil {
+			lisenner.Close()
+			return fmt.Errorf("udp listen: %w", err)
 		}
-		fmt.Println("start Zinx server success", s.Name, "succ,Listenning...")
-		var cid uint32
-		cid = 0
+	}
+	s.listener = lisenner
+
+	//2 开启开启消息队列及工作池
+	s.MsgHandler.StartWorkerPool()
+	//开启WebSocket监听
+	if utils.Global().WsPort > 0 {
+		s.startWebsocket()
+	}
+	//开启指标监听
+	s.registerMetrics()
+	if utils.Global().MetricsPort > 0 {
+		s.startMetrics()
+	}
+	//开启管理接口监听
+	if utils.Global().AdminPort > 0 {
+		s.startAdmin()
+	}
+
+	zlog.Info("start Zinx server success", "name", s.Name, "addr", lisenner.Addr().String(), "tls", s.tlsConfig != nil)
+	go func() {
 		//3 阻塞的等待客户端链接，处理客户端链接业务（读写）
 		for {
 			//如果有客户端链接过来，阻塞会返回
-			conn, err := lisenner.AcceptTCP()
+			conn, err := lisenner.Accept()
 			if err != nil {
-				fmt.Println("Accept err", err)
+				//服务器关闭，停止接收新的链接
+				select {
+				case <-s.exitChan:
+					zlog.Info("stop accept, listener closed")
+					return
+				default:
+				}
+				zlog.Warn("accept error", "err", err)
 				continue
 			}
-
-			//设置最大连接个数的判断，如果超过最大连接，那么则关闭此新的连接
-			if s.ConnMgr.Len() >= utils.GlobalObject.MaxConn {
-				//todo 给客户端响应一个错误包
-				fmt.Println("Too Many Connection MaxConn =", utils.GlobalObject.MaxConn)
-				conn.Close()
-				continue
-			}
-			//将处理新链接的业务方法和conn进行绑定，得到我们的链接模块
-			dealConn := NewConnection(s, conn, cid, s.MsgHandler)
-			cid++
-			//启动当前的链接业务处理
-			go dealConn.Start()
+			s.handleConn(conn)
 		}
 	}()
+	return nil
+}
 
+// 处理一个新建立的链接，TCP和WebSocket链接都走这里
+func (s *Server) handleConn(conn net.Conn) {
+	s.exitLock.Lock()
+	defer s.exitLock.Unlock()
+	//服务器正在关闭，不再接收新的链接
+	select {
+	case <-s.exitChan:
+		conn.Close()
+		return
+	default:
+	}
+	//准入检查不通过时，告知客户端拒绝原因之后关闭链接
+	if reason := s.admit(conn); reason != RefuseNone {
+		s.connWg.Add(1)
+		go func() {
+			defer s.connWg.Done()
+			s.refuse(conn, reason)
+		}()
+		return
+	}
+	//将处理新链接的业务方法和conn进行绑定，得到我们的链接模块
+	cid := atomic.AddUint32(&s.cid, 1) - 1
+	dealConn := NewConnection(s, conn, cid, s.MsgHandler)
+	//启动当前的链接业务处理，等待链接的读写和心跳goroutine全部退出
+	s.connWg.Add(1)
+	go func() {
+		defer s.connWg.Done()
+		dealConn.Start()
+		dealConn.wg.Wait()
+	}()
 }
 func (s *Server) Stop() {
-	// 将一些服务器资源状态，已经开辟的链接消息停止
-	fmt.Println("[STOP] Zinx server name", s.Name)
-	s.ConnMgr.ClearConn()
+	s.stopOnce.Do(func() {
+		// 将一些服务器资源状态，已经开辟的链接消息停止
+		zlog.Info("server is stopping", "name", s.Name)
+		//1 停止接收新的链接
+		s.exitLock.Lock()
+		close(s.exitChan)
+		s.exitLock.Unlock()
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		if s.wsServer != nil {
+			s.wsServer.Close()
+		}
+		if s.metricsServer != nil {
+			s.metricsServer.Close()
+		}
+		if s.adminServer != nil {
+			s.adminServer.Close()
+		}
+		if s.udp != nil {
+			s.udp.close()
+		}
+		//2 等待工作池把已经收到的消息处理完毕
+		s.MsgHandler.StopWorkerPool()
+		//3 断开所有链接，每个链接都会调用OnConnStop
+		s.ConnMgr.ClearConn()
+		//4 等待链接的goroutine全部退出，之后不会再有链接读取配置或者调用钩子
+		s.connWg.Wait()
+		zlog.Info("server stopped", "name", s.Name)
+	})
 }
-func (s *Server) Serve() {
-	//启动server的服务功能
-	s.Start()
+func (s *Server) Serve() error {
+	//启动server的服务功能，监听失败时直接返回
+	if err := s.Start(); err != nil {
+		return err
+	}
 
-	//todo 做一些服务器之后的额外业务
-	//阻塞状态
-	select {}
+	//阻塞等待退出信号，收到SIGHUP时重新加载配置
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	sig := <-sigChan
+	for sig == syscall.SIGHUP {
+		s.ReloadConfig()
+		sig = <-sigChan
+	}
+	zlog.Info("recv signal, server is shutting down", "signal", sig.String())
+
+	//优雅关闭，超过配置的时间之后强制退出
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	timeout := time.Duration(utils.Global().ShutdownTimeout) * time.Second
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		zlog.Warn("shutdown timeout, force exit", "timeout", timeout)
+	}
+	return nil
 }
 
 func (s *Server) GetConnMgr() ziface.IConnManager {
@@ -135,19 +293,51 @@ func (s *Server) GetConnMgr() ziface.IConnManager {
 
 }
 
+// 获取消息管理模块
+func (s *Server) GetMsgHandler() ziface.IMsgHanle {
+	return s.MsgHandler
+}
+
+// 设置封包拆包模块
+func (s *Server) SetPacket(packet ziface.IDataPack) {
+	s.Packet = packet
+}
+
+// 获取封包拆包模块
+func (s *Server) GetPacket() ziface.IDataPack {
+	return s.Packet
+}
+
 /*
   创建一个服务器句柄
 */
 // 初始化server模块的方法
 func NewServer() ziface.IServer {
 	s := &Server{
-		Name:       utils.GlobalObject.Name,
+		Name:       utils.Global().Name,
 		IPVersion:  "tcp4",
-		IP:         utils.GlobalObject.Host,
-		Port:       utils.GlobalObject.TcpPort,
+		IP:         utils.Global().Host,
+		Port:       utils.Global().TcpPort,
 		MsgHandler: NewMsgHandle(),
 		ConnMgr:    NewManager(),
+		Lock:       new(sync.RWMutex),
+		exitChan:   make(chan struct{}),
+		ipFilter:   newIPFilter(),
+	}
+	//根据配置选择封包格式
+	packet, err := NewDataPackByName(utils.Global().PacketCodec)
+	if err != nil {
+		zlog.Warn("use default packet codec", "err", err)
+		packet = NewDataPack()
 	}
+	if !supportFlags(packet) {
+		zlog.Info("packet codec does not support msg flags, request seq and compression are disabled", "codec", utils.Global().PacketCodec)
+	}
+	s.Packet = packet
+	//配置是只读的，复制一份设置TcpServer之后替换
+	g := *utils.Global()
+	g.TcpServer = s
+	utils.SetGlobal(&g)
 	return s
 }
 
@@ -164,15 +354,17 @@ func (s *Server) SetOnConnStop(hookFunc func(connection ziface.IConnection)) {
 // 调用OnConnStart钩子函数的方法
 func (s *Server) CallOnConnStart(connection ziface.IConnection) {
 	if s.OnConnStart != nil {
-		fmt.Println("---->Call onConnStart()...")
 		s.OnConnStart(connection)
 	}
 }
 
 // 调用OnConnStop钩子函数的方法
 func (s *Server) CallOnConnStop(connection ziface.IConnection) {
+	//释放链接占用的IP名额
+	if ip := remoteIP(connection.RemoteAddr()); ip != nil {
+		s.ipFilter.release(ip)
+	}
 	if s.OnConnStop != nil {
-		fmt.Println("---->Call onConnStop()...")
 		s.OnConnStop(connection)
 	}
 }
@@ -0,0 +1,85 @@
+package znet
+
+import (
+	"fmt"
+	"net/http"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"server-1.1.0/network/zlog"
+	"server-1.1.0/network/zmetrics"
+	"strconv"
+)
+
+// 框架的指标
+var (
+	requestsTotal      = zmetrics.NewCounterVec("zinx_requests_total", "Requests handled by msgID.", "msgid")
+	requestDuration    = zmetrics.NewHistogramVec("zinx_request_duration_seconds", "Handler latency by msgID, including middlewares.", zmetrics.DefBuckets, "msgid")
+	bytesReceivedTotal = zmetrics.NewCounterVec("zinx_bytes_received_total", "Bytes received from all connections.")
+	bytesSentTotal     = zmetrics.NewCounterVec("zinx_bytes_sent_total", "Bytes sent to all connections.")
+	refusedTotal       = zmetrics.NewCounterVec("zinx_connections_refused_total", "Connections refused before creation by reason.", "reason")
+	udpDroppedTotal    = zmetrics.NewCounterVec("zinx_udp_dropped_total", "UDP packets dropped by reason.", "reason")
+)
+
+// 注册和Server相关的指标，输出时从链接管理器和工作池获取
+func (s *Server) registerMetrics() {
+	zmetrics.NewGaugeFunc("zinx_connections", "Current connections.", func() float64 {
+		return float64(s.ConnMgr.Len())
+	})
+	zmetrics.NewGaugeVecFunc("zinx_connection_bytes_received", "Bytes received by connection.", []string{"conn_id"},
+		func(emit func(value float64, labelValues ...string)) {
+			s.ConnMgr.Range(func(conn ziface.IConnection) {
+				emit(float64(conn.BytesReceived()), strconv.FormatUint(uint64(conn.GetConnId()), 10))
+			})
+		})
+	zmetrics.NewGaugeVecFunc("zinx_connection_bytes_sent", "Bytes sent by connection.", []string{"conn_id"},
+		func(emit func(value float64, labelValues ...string)) {
+			s.ConnMgr.Range(func(conn ziface.IConnection) {
+				emit(float64(conn.BytesSent()), strconv.FormatUint(uint64(conn.GetConnId()), 10))
+			})
+		})
+
+	zmetrics.NewGaugeFunc("zinx_udp_sessions", "Current UDP sessions.", func() float64 {
+		if s.udp == nil {
+			return 0
+		}
+		return float64(s.udp.len())
+	})
+
+	zmetrics.NewGaugeFunc("zinx_worker_pool_size", "Current workers.", func() float64 {
+		return float64(s.MsgHandler.GetWorkerPoolStats().WorkerPoolSize)
+	})
+	zmetrics.NewGaugeVecFunc("zinx_worker_queue_length", "Requests waiting in the worker task queue.", []string{"worker"},
+		func(emit func(value float64, labelValues ...string)) {
+			for i, n := range s.MsgHandler.GetWorkerPoolStats().QueueLens {
+				emit(float64(n), strconv.Itoa(i))
+			}
+		})
+	zmetrics.NewGaugeVecFunc("zinx_worker_overflow_length", "Requests waiting in the worker overflow queue.", []string{"worker"},
+		func(emit func(value float64, labelValues ...string)) {
+			for i, n := range s.MsgHandler.GetWorkerPoolStats().OverflowLens {
+				emit(float64(n), strconv.Itoa(i))
+			}
+		})
+	zmetrics.NewCounterFunc("zinx_worker_rejected_total", "Requests rejected because the task queue was full.", func() float64 {
+		return float64(s.MsgHandler.GetWorkerPoolStats().Rejected)
+	})
+	zmetrics.NewCounterFunc("zinx_worker_spilled_total", "Requests spilled to the overflow queue.", func() float64 {
+		return float64(s.MsgHandler.GetWorkerPoolStats().Spilled)
+	})
+}
+
+// 开启指标的http监听
+func (s *Server) startMetrics() {
+	mux := http.NewServeMux()
+	mux.Handle(utils.Global().MetricsPath, zmetrics.Handler())
+	s.metricsServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", s.IP, utils.Global().MetricsPort),
+		Handler: mux,
+	}
+	go func() {
+		zlog.Info("start metrics server", "addr", s.metricsServer.Addr, "path", utils.Global().MetricsPath)
+		if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zlog.Error("metrics server error", "err", err)
+		}
+	}()
+}
@@ -0,0 +1,508 @@
+package znet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试用的回显路由，把收到的数据原样发回
+type echoRouter struct {
+	BaseRouter
+}
+
+func (e *echoRouter) Handle(request ziface.IRequest) {
+	request.GetConnection().SendMsg(request.GetMsgID(), request.GetData())
+}
+
+// 测试时生成的证书
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// 生成一个证书，parent为nil时生成自签名证书
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	parentCert, parentKey := template, key
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	if err := os.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return tc
+}
+
+// 获取一个空闲的本地端口
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// 复制一份全局配置按测试需要修改之后替换，测试结束时恢复原来的配置
+func setTestConfig(t *testing.T, config func(g *utils.GlobalObj)) {
+	old := utils.Global()
+	g := *old
+	config(&g)
+	utils.SetGlobal(&g)
+	t.Cleanup(func() { utils.SetGlobal(old) })
+}
+
+// 按测试需要修改全局配置并启动一个回显服务器，测试结束时先关闭服务器再恢复配置
+func startTestServer(t *testing.T, config func(g *utils.GlobalObj)) ziface.IServer {
+	port := freePort(t)
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.Host = "127.0.0.1"
+		g.TcpPort = port
+		g.WsPort = 0
+		config(g)
+	})
+
+	s := NewServer()
+	s.AddRouter(1, &echoRouter{})
+	if err := s.Start(); err != nil {
+		t.Fatal("start server err:", err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+// 拨号直到测试服务器开始监听
+func dialTestServer(t *testing.T) net.Conn {
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			return conn
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("dial err:", err)
+	return nil
+}
+
+// 拨号直到服务器开始监听
+func dialTLS(addr string, config *tls.Config) (*tls.Conn, error) {
+	var conn *tls.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		conn, err = tls.Dial("tcp", addr, config)
+		if err == nil {
+			return conn, nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil, err
+}
+
+// 发送一个消息并等待回显
+func echoOverConn(conn net.Conn, data []byte) (ziface.IMessage, error) {
+	dp := NewDataPack()
+	binaryMsg, err := dp.Pack(NewMsgPackage(1, data))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(binaryMsg); err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	return dp.Unpack(conn)
+}
+
+func TestServerTLS(t *testing.T) {
+	serverCert := newTestCert(t, "server", false, nil)
+	startTestServer(t, func(g *utils.GlobalObj) {
+		g.CertFile = serverCert.certFile
+		g.KeyFile = serverCert.keyFile
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.cert)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	conn, err := dialTLS(addr, &tls.Config{RootCAs: roots})
+	if err != nil {
+		t.Fatal("dial tls err:", err)
+	}
+	defer conn.Close()
+
+	msg, err := echoOverConn(conn, []byte("hello tls"))
+	if err != nil {
+		t.Fatal("echo err:", err)
+	}
+	if string(msg.GetData()) != "hello tls" {
+		t.Errorf("echo data = %s, want hello tls", msg.GetData())
+	}
+}
+
+func TestServerTLSClientCA(t *testing.T) {
+	serverCert := newTestCert(t, "server", false, nil)
+	ca := newTestCert(t, "ca", true, nil)
+	toolCert := newTestCert(t, "tool", false, ca)
+	strangerCert := newTestCert(t, "stranger", false, nil)
+	startTestServer(t, func(g *utils.GlobalObj) {
+		g.CertFile = serverCert.certFile
+		g.KeyFile = serverCert.keyFile
+		g.ClientCAFile = ca.certFile
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.cert)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+
+	clientConfig := func(c *testCert) *tls.Config {
+		config := &tls.Config{RootCAs: roots}
+		if c != nil {
+			pair, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//总是发送证书，不管是否由服务器要求的CA签发
+			config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return &pair, nil
+			}
+		}
+		return config
+	}
+
+	//CA签发的客户端证书和不带证书的玩家客户端都可以通信
+	for name, c := range map[string]*testCert{"tool": toolCert, "player": nil} {
+		conn, err := dialTLS(addr, clientConfig(c))
+		if err != nil {
+			t.Fatal(name, "dial tls err:", err)
+		}
+		if _, err := echoOverConn(conn, []byte(name)); err != nil {
+			t.Error(name, "echo err:", err)
+		}
+		conn.Close()
+	}
+
+	//不是CA签发的客户端证书被拒绝
+	conn, err := dialTLS(addr, clientConfig(strangerCert))
+	if err == nil {
+		_, err = echoOverConn(conn, []byte("stranger"))
+		conn.Close()
+	}
+	if err == nil {
+		t.Error("client cert not signed by ca should be rejected")
+	}
+}
+
+// 准入检查不通过时，客户端收到拒绝原因之后链接被关闭
+func TestServerRefuse(t *testing.T) {
+	s := NewServer().(*Server)
+	s.AddAdmitHook(func(conn net.Conn) uint32 {
+		return RefuseCustom + 1
+	})
+
+	cases := []struct {
+		name   string
+		config func(g *utils.GlobalObj)
+		reason uint32
+	}{
+		{"maintenance", func(g *utils.GlobalObj) { g.Maintenance = true; g.MaxConn = 0 }, RefuseMaintenance},
+		{"server full", func(g *utils.GlobalObj) { g.Maintenance = false; g.MaxConn = 0 }, RefuseServerFull},
+		{"admit hook", func(g *utils.GlobalObj) { g.Maintenance = false; g.MaxConn = 10 }, RefuseCustom + 1},
+	}
+	for _, c := range cases {
+		setTestConfig(t, c.config)
+		serverSide, clientSide := net.Pipe()
+		s.handleConn(serverSide)
+
+		clientSide.SetReadDeadline(time.Now().Add(3 * time.Second))
+		msg, err := s.GetPacket().Unpack(clientSide)
+		if err != nil {
+			t.Fatal(c.name, "unpack err:", err)
+		}
+		if msg.GetMsgId() != RefuseMsgID || len(msg.GetData()) != 4 {
+			t.Fatalf("%s: msgID = %d, len = %d, want refuse msg", c.name, msg.GetMsgId(), len(msg.GetData()))
+		}
+		if reason := binary.LittleEndian.Uint32(msg.GetData()); reason != c.reason {
+			t.Errorf("%s: reason = %d, want %d", c.name, reason, c.reason)
+		}
+		if _, err := clientSide.Read(make([]byte, 1)); err == nil {
+			t.Errorf("%s: connection should be closed after refuse", c.name)
+		}
+		if s.ConnMgr.Len() != 0 {
+			t.Errorf("%s: refused connection should not be added to ConnManager", c.name)
+		}
+		clientSide.Close()
+	}
+}
+
+// 读取服务器发送的拒绝原因
+func readRefuse(t *testing.T, conn net.Conn) uint32 {
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	msg, err := NewDataPack().Unpack(conn)
+	if err != nil {
+		t.Fatal("unpack refuse err:", err)
+	}
+	if msg.GetMsgId() != RefuseMsgID {
+		t.Fatalf("msgID = %d, want %d", msg.GetMsgId(), RefuseMsgID)
+	}
+	return binary.LittleEndian.Uint32(msg.GetData())
+}
+
+// 每个IP的链接数限制，断开之后释放名额，运行时更新黑名单
+func TestServerIPFilter(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) {
+		g.MaxConnPerIP = 1
+		g.IPAllowList = []string{"127.0.0.0/8"}
+	})
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	dial := func() net.Conn {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 50; i++ {
+			if conn, err = net.Dial("tcp", addr); err == nil {
+				return conn
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatal("dial err:", err)
+		return nil
+	}
+
+	first := dial()
+	if _, err := echoOverConn(first, []byte("first")); err != nil {
+		t.Fatal("echo err:", err)
+	}
+	second := dial()
+	if reason := readRefuse(t, second); reason != RefuseTooManyConns {
+		t.Errorf("reason = %d, want %d", reason, RefuseTooManyConns)
+	}
+	second.Close()
+
+	//断开之后名额被释放
+	first.Close()
+	deadline := time.Now().Add(3 * time.Second)
+	for s.GetConnMgr().Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	third := dial()
+	if _, err := echoOverConn(third, []byte("third")); err != nil {
+		t.Fatal("echo after release err:", err)
+	}
+	third.Close()
+
+	if err := s.UpdateIPFilter(nil, []string{"bad-ip"}, 0); err == nil {
+		t.Error("invalid ip should fail")
+	}
+	if err := s.UpdateIPFilter(nil, []string{"127.0.0.1"}, 0); err != nil {
+		t.Fatal("UpdateIPFilter err:", err)
+	}
+	banned := dial()
+	defer banned.Close()
+	if reason := readRefuse(t, banned); reason != RefuseBannedIP {
+		t.Errorf("reason = %d, want %d", reason, RefuseBannedIP)
+	}
+}
+
+// 端口被占用时Start返回错误，Start返回之后立即Stop，监听一定被关闭
+func TestServerStartListen(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	setTestConfig(t, func(g *utils.GlobalObj) {
+		g.Host = "127.0.0.1"
+		g.TcpPort = busy.Addr().(*net.TCPAddr).Port
+		g.WsPort = 0
+	})
+	if err := NewServer().Start(); err == nil {
+		t.Error("start on a busy port should return error")
+	}
+
+	s := startTestServer(t, func(g *utils.GlobalObj) {})
+	s.Stop()
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Error("listener should be closed after stop")
+	}
+}
+
+// Stop返回之前所有链接都已经断开，OnConnStop已经调用完毕，之后的新链接直接关闭
+func TestServerStopWaitsConnections(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) { g.MaxIdleTime = 60 }).(*Server)
+	var stopped int32
+	s.SetOnConnStop(func(conn ziface.IConnection) {
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&stopped, 1)
+	})
+
+	conn := dialTestServer(t)
+	defer conn.Close()
+	if _, err := echoOverConn(conn, []byte("stop")); err != nil {
+		t.Fatal("echo err:", err)
+	}
+
+	s.Stop()
+	if atomic.LoadInt32(&stopped) != 1 {
+		t.Error("OnConnStop should finish before Stop returns")
+	}
+	if s.ConnMgr.Len() != 0 {
+		t.Errorf("conn num = %d after stop, want 0", s.ConnMgr.Len())
+	}
+
+	serverSide, clientSide := net.Pipe()
+	defer clientSide.Close()
+	s.handleConn(serverSide)
+	if _, err := clientSide.Read(make([]byte, 1)); err == nil {
+		t.Error("connection after stop should be closed")
+	}
+	if s.ConnMgr.Len() != 0 {
+		t.Error("connection after stop should not be added to ConnManager")
+	}
+}
+
+// 第一个消息处理之前一直等待的路由，记录处理过的消息数量
+type gateRouter struct {
+	BaseRouter
+	gate    chan struct{}
+	handled int32
+}
+
+func (r *gateRouter) Handle(request ziface.IRequest) {
+	<-r.gate
+	atomic.AddInt32(&r.handled, 1)
+}
+
+// 关闭服务器时工作池先处理完已经收到的消息，再断开链接调用OnConnStop
+func TestServerStopDrainsWorkerPool(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) { g.WorkerPoolSize = 2 }).(*Server)
+	router := &gateRouter{gate: make(chan struct{})}
+	s.AddRouter(2, router)
+	var handledAtStop int32 = -1
+	s.SetOnConnStop(func(conn ziface.IConnection) {
+		atomic.StoreInt32(&handledAtStop, atomic.LoadInt32(&router.handled))
+	})
+
+	conn := dialTestServer(t)
+	defer conn.Close()
+	dp := NewDataPack()
+	var sent uint64
+	for i := 0; i < 5; i++ {
+		binaryMsg, _ := dp.Pack(NewMsgPackage(2, []byte{byte(i)}))
+		if _, err := conn.Write(binaryMsg); err != nil {
+			t.Fatal("write err:", err)
+		}
+		sent += uint64(len(binaryMsg))
+	}
+	//等待服务器收到全部消息，此时消息都在工作池的队列中
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if c, err := s.ConnMgr.Get(0); err == nil && c.(*Connection).BytesReceived() == sent {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(router.gate)
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("stop timeout")
+	}
+	if handled := atomic.LoadInt32(&router.handled); handled != 5 {
+		t.Errorf("handled = %d, want 5", handled)
+	}
+	if handled := atomic.LoadInt32(&handledAtStop); handled != 5 {
+		t.Errorf("handled when OnConnStop = %d, want 5", handled)
+	}
+}
+
+// TLS链接被拒绝时客户端不发送握手，服务器等待超时之后关闭链接，不会一直阻塞
+func TestServerRefuseTLSWithoutHandshake(t *testing.T) {
+	serverCert := newTestCert(t, "server", false, nil)
+	s := startTestServer(t, func(g *utils.GlobalObj) {
+		g.CertFile = serverCert.certFile
+		g.KeyFile = serverCert.keyFile
+		g.MaxConn = 0
+	})
+
+	conn := dialTestServer(t)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(3 * refuseWriteTimeout))
+	if _, err := conn.Read(make([]byte, 1)); errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatal("refused connection should be closed after refuseWriteTimeout")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("stop should not wait for refused connection")
+	}
+}
@@ -0,0 +1,45 @@
+package znet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"server-1.1.0/network/utils"
+)
+
+// 根据zinx.json中的证书配置创建TLS配置，没有配置证书时返回nil
+func newTLSConfig() (*tls.Config, error) {
+	certFile := utils.Global().CertFile
+	keyFile := utils.Global().KeyFile
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls need both CertFile and KeyFile")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	//内部工具使用客户端证书，玩家客户端不需要提供证书
+	if utils.Global().ClientCAFile != "" {
+		caData, err := os.ReadFile(utils.Global().ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, errors.New("no client ca found in " + utils.Global().ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
@@ -0,0 +1,51 @@
+package znet
+
+import (
+	"fmt"
+	"net/http"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket升级器，网页和小游戏客户端的Origin各不相同，这里不做限制
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// 开启WebSocket监听，升级成功的链接和TCP链接一样交给handleConn处理
+func (s *Server) startWebsocket() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(utils.Global().WsPath, func(w http.ResponseWriter, r *http.Request) {
+		ws, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			zlog.Warn("websocket upgrade error", "remoteAddr", r.RemoteAddr, "err", err)
+			return
+		}
+		s.handleConn(newWsConn(ws))
+	})
+	s.wsServer = &http.Server{
+		Addr:      fmt.Sprintf("%s:%d", s.IP, utils.Global().WsPort),
+		Handler:   mux,
+		TLSConfig: s.tlsConfig,
+	}
+
+	go func() {
+		zlog.Info("start Zinx websocket success", "name", s.Name, "addr", s.wsServer.Addr, "path", utils.Global().WsPath)
+		var err error
+		if s.tlsConfig != nil {
+			//证书已经在TLSConfig中，这里不需要再传文件路径
+			err = s.wsServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.wsServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			zlog.Error("websocket listen error", "err", err)
+		}
+	}()
+}
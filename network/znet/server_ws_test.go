@@ -0,0 +1,58 @@
+package znet
+
+import (
+	"bytes"
+	"fmt"
+	"server-1.1.0/network/utils"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket链接和TCP链接使用相同的封包格式和路由，每个二进制WS消息是一个完整的封包
+func TestServerWebsocket(t *testing.T) {
+	port := freePort(t)
+	s := startTestServer(t, func(g *utils.GlobalObj) { g.WsPort = port })
+
+	url := fmt.Sprintf("ws://127.0.0.1:%d%s", port, utils.Global().WsPath)
+	var ws *websocket.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if ws, _, err = websocket.DefaultDialer.Dial(url, nil); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("dial websocket err:", err)
+	}
+	defer ws.Close()
+
+	dp := NewDataPack()
+	binaryMsg, err := dp.Pack(NewMsgPackage(1, []byte("hello ws")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.WriteMessage(websocket.BinaryMessage, binaryMsg); err != nil {
+		t.Fatal("write err:", err)
+	}
+	ws.SetReadDeadline(time.Now().Add(3 * time.Second))
+	msgType, data, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal("read err:", err)
+	}
+	if msgType != websocket.BinaryMessage {
+		t.Errorf("msgType = %d, want binary", msgType)
+	}
+	msg, err := dp.Unpack(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("unpack err:", err)
+	}
+	if msg.GetMsgId() != 1 || string(msg.GetData()) != "hello ws" {
+		t.Errorf("echo = %d %s, want 1 hello ws", msg.GetMsgId(), msg.GetData())
+	}
+	if s.GetConnMgr().Len() != 1 {
+		t.Errorf("conn num = %d, want 1", s.GetConnMgr().Len())
+	}
+}
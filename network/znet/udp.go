@@ -0,0 +1,289 @@
+package znet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/zlog"
+	"sync"
+	"sync/atomic"
+)
+
+// UDP数据报 | token 8 | seq 4 | msgID 4 | data |，全部小端
+// token由服务器通过TCP的UdpTokenMsgID下发，seq每个方向单独递增，收到比之前旧的数据报直接丢弃
+// 只有序号比之前新的数据报才会更新客户端地址，UdpHello也使用同一个序号
+const udpHeadLen = 16
+
+// 单个数据报的最大长度，超过时改用TCP发送，避免IP分片
+const maxUdpPacketSize = 1200
+
+// 绑定在一个TCP链接上的UDP会话
+type udpSession struct {
+	token uint64
+	conn  *Connection
+	mgr   *udpManager
+
+	//客户端的UDP地址，收到数据报之后绑定，客户端地址变化时更新
+	addrLock sync.RWMutex
+	addr     *net.UDPAddr
+	//已经收到的最大序号，只在UDP的读取goroutine中使用
+	recvSeq     uint32
+	recvStarted bool
+	//发送的序号
+	sendSeq uint32
+}
+
+// 绑定客户端地址
+func (s *udpSession) bind(addr *net.UDPAddr) {
+	s.addrLock.RLock()
+	same := s.addr != nil && s.addr.IP.Equal(addr.IP) && s.addr.Port == addr.Port
+	s.addrLock.RUnlock()
+	if same {
+		return
+	}
+	s.addrLock.Lock()
+	s.addr = addr
+	s.addrLock.Unlock()
+	s.conn.logger.Debug("udp session bind", "udpAddr", addr.String())
+}
+
+func (s *udpSession) getAddr() *net.UDPAddr {
+	s.addrLock.RLock()
+	defer s.addrLock.RUnlock()
+	return s.addr
+}
+
+// 判断序号是否比已经收到的新，按照int32比较处理回绕
+func (s *udpSession) acceptSeq(seq uint32) bool {
+	if s.recvStarted && int32(seq-s.recvSeq) <= 0 {
+		return false
+	}
+	s.recvSeq = seq
+	s.recvStarted = true
+	return true
+}
+
+// 通过UDP发送数据，还没有绑定客户端地址或者数据太大时返回false，由调用者改用TCP发送
+func (s *udpSession) send(msgID uint32, data []byte) (bool, error) {
+	addr := s.getAddr()
+	if addr == nil || udpHeadLen+len(data) > maxUdpPacketSize {
+		return false, nil
+	}
+	packet := make([]byte, udpHeadLen+len(data))
+	binary.LittleEndian.PutUint64(packet, s.token)
+	binary.LittleEndian.PutUint32(packet[8:], atomic.AddUint32(&s.sendSeq, 1))
+	binary.LittleEndian.PutUint32(packet[12:], msgID)
+	copy(packet[udpHeadLen:], data)
+
+	s.conn.record(CaptureOut, true, msgID, 0, data)
+	n, err := s.mgr.conn.WriteToUDP(packet, addr)
+	s.conn.addBytesSent(n)
+	return true, err
+}
+
+// UDP监听和所有UDP会话
+type udpManager struct {
+	conn     *net.UDPConn
+	lock     sync.RWMutex
+	sessions map[uint64]*udpSession
+}
+
+// 开启UDP监听
+func (s *Server) startUdp() error {
+	addr, err := net.ResolveUDPAddr("udp4", fmt.Sprintf("%s:%d", s.IP, utils.Global().UdpPort))
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp4", addr)
+	if err != nil {
+		return err
+	}
+	s.udp = &udpManager{
+		conn:     conn,
+		sessions: make(map[uint64]*udpSession),
+	}
+	zlog.Info("start Zinx udp success", "name", s.Name, "addr", conn.LocalAddr().String())
+	go s.udp.serve()
+	return nil
+}
+
+// UDP监听的端口号
+func (m *udpManager) port() int {
+	return m.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// 为链接创建一个UDP会话，生成不重复的随机token
+func (m *udpManager) newSession(c *Connection) (*udpSession, error) {
+	buf := make([]byte, 8)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		token := binary.LittleEndian.Uint64(buf)
+		if _, ok := m.sessions[token]; ok || token == 0 {
+			continue
+		}
+		session := &udpSession{token: token, conn: c, mgr: m}
+		m.sessions[token] = session
+		return session, nil
+	}
+}
+
+func (m *udpManager) get(token uint64) *udpSession {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.sessions[token]
+}
+
+func (m *udpManager) remove(token uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.sessions, token)
+}
+
+// 当前UDP会话数量
+func (m *udpManager) len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.sessions)
+}
+
+func (m *udpManager) close() {
+	m.conn.Close()
+}
+
+// 不断读取数据报，交给对应会话的链接处理
+func (m *udpManager) serve() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, addr, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			zlog.Warn("udp read error", "err", err)
+			continue
+		}
+		m.handlePacket(buf[:n], addr)
+	}
+}
+
+// 处理一个数据报，token不存在、序号过旧或者格式错误的数据报直接丢弃
+func (m *udpManager) handlePacket(packet []byte, addr *net.UDPAddr) {
+	if len(packet) < udpHeadLen || len(packet)-udpHeadLen > int(utils.Global().MaxPacketSize) {
+		udpDroppedTotal.Inc("bad_packet")
+		return
+	}
+	session := m.get(binary.LittleEndian.Uint64(packet))
+	if session == nil {
+		udpDroppedTotal.Inc("unknown_token")
+		return
+	}
+	seq := binary.LittleEndian.Uint32(packet[8:])
+	msgID := binary.LittleEndian.Uint32(packet[12:])
+	c := session.conn
+	c.addBytesReceived(len(packet))
+	//先检查序号再绑定地址，重放旧的数据报不能把发给客户端的数据引到其他地址
+	if !session.acceptSeq(seq) {
+		udpDroppedTotal.Inc("stale")
+		return
+	}
+	session.bind(addr)
+
+	//客户端用来确认UDP可用，原样回复
+	if msgID == UdpHelloMsgID {
+		if _, err := session.send(UdpHelloMsgID, nil); err != nil {
+			c.logger.Debug("send udp hello error", "err", err)
+		}
+		return
+	}
+	c.record(CaptureIn, true, msgID, 0, packet[udpHeadLen:])
+	if IsReservedMsgID(msgID) {
+		udpDroppedTotal.Inc("bad_packet")
+		return
+	}
+	//只有配置的msgID可以通过UDP发送，其他消息必须走TCP
+	if !udpAllowed(msgID) {
+		udpDroppedTotal.Inc("not_allowed")
+		return
+	}
+	c.updateActivity()
+	if !c.checkRateLimit(msgID) {
+		return
+	}
+
+	data := make([]byte, len(packet)-udpHeadLen)
+	copy(data, packet[udpHeadLen:])
+	c.dispatch(&Request{
+		conn: c,
+		msg:  NewMsgPackage(msgID, data),
+	})
+}
+
+// 是否可以通过UDP接收，只有UdpMsgIDs中的msgID交给业务处理
+func udpAllowed(msgID uint32) bool {
+	for _, id := range utils.Global().UdpMsgIDs {
+		if id == msgID {
+			return true
+		}
+	}
+	return false
+}
+
+// 开启UDP通道，通过TCP把token和UDP端口发送给客户端，重复调用时重新发送同一个token
+func (c *Connection) StartUnreliable() error {
+	server, ok := c.TcpServer.(*Server)
+	if !ok || server.udp == nil {
+		return errors.New("udp is not enabled")
+	}
+	c.closeLock.Lock()
+	if c.isClosed {
+		c.closeLock.Unlock()
+		return errors.New("Connection closed when start unreliable")
+	}
+	session := c.udp
+	if session == nil {
+		var err error
+		if session, err = server.udp.newSession(c); err != nil {
+			c.closeLock.Unlock()
+			return err
+		}
+		c.udp = session
+	}
+	c.closeLock.Unlock()
+
+	//| token 8 | port 2 |
+	data := make([]byte, 10)
+	binary.LittleEndian.PutUint64(data, session.token)
+	binary.LittleEndian.PutUint16(data[8:], uint16(server.udp.port()))
+	return c.SendBuffMsg(UdpTokenMsgID, data)
+}
+
+// 通过UDP发送可以丢弃的消息，UDP通道不可用时按照SendDroppableMsg通过TCP发送
+func (c *Connection) SendUnreliableMsg(msgId uint32, data []byte) error {
+	c.closeLock.Lock()
+	closed, session := c.isClosed, c.udp
+	c.closeLock.Unlock()
+	if closed {
+		return errors.New("Connection closed when send unreliable msg")
+	}
+	if session != nil {
+		if sent, err := session.send(msgId, data); sent {
+			return err
+		}
+	}
+	return c.SendDroppableMsg(msgId, data)
+}
+
+// 链接关闭时移除UDP会话，调用时已经持有closeLock
+func (c *Connection) closeUnreliable() {
+	if c.udp != nil {
+		c.udp.mgr.remove(c.udp.token)
+		c.udp = nil
+	}
+}
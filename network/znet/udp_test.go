@@ -0,0 +1,162 @@
+package znet
+
+import (
+	"encoding/binary"
+	"net"
+	"server-1.1.0/network/utils"
+	"server-1.1.0/network/ziface"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 收到之后开启UDP通道
+type startUnreliableRouter struct {
+	BaseRouter
+}
+
+func (r *startUnreliableRouter) Handle(request ziface.IRequest) {
+	request.GetConnection().StartUnreliable()
+}
+
+// 通过UDP回显
+type unreliableEchoRouter struct {
+	BaseRouter
+}
+
+func (r *unreliableEchoRouter) Handle(request ziface.IRequest) {
+	request.GetConnection().SendUnreliableMsg(request.GetMsgID(), request.GetData())
+}
+
+func packUdp(token uint64, seq, msgID uint32, data []byte) []byte {
+	packet := make([]byte, udpHeadLen+len(data))
+	binary.LittleEndian.PutUint64(packet, token)
+	binary.LittleEndian.PutUint32(packet[8:], seq)
+	binary.LittleEndian.PutUint32(packet[12:], msgID)
+	copy(packet[udpHeadLen:], data)
+	return packet
+}
+
+// 读取一个数据报，返回seq、msgID和数据
+func readUdp(t *testing.T, conn *net.UDPConn) (uint32, uint32, []byte) {
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal("read udp err:", err)
+	}
+	if n < udpHeadLen {
+		t.Fatalf("udp packet len = %d", n)
+	}
+	return binary.LittleEndian.Uint32(buf[8:]), binary.LittleEndian.Uint32(buf[12:]), buf[udpHeadLen:n]
+}
+
+// 读取超时说明没有收到数据报
+func expectNoUdp(t *testing.T, conn *net.UDPConn, what string) {
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := conn.Read(buf); err == nil {
+		t.Errorf("%s: unexpected udp packet len %d", what, n)
+	}
+}
+
+// TCP下发token之后通过UDP收发消息，旧的序号被丢弃，不在UdpMsgIDs中的消息被丢弃
+func TestServerUdp(t *testing.T) {
+	s := startTestServer(t, func(g *utils.GlobalObj) {
+		g.UdpPort = freePort(t)
+		g.UdpMsgIDs = []uint32{3}
+	})
+	s.AddRouter(2, &startUnreliableRouter{})
+	s.AddRouter(3, &unreliableEchoRouter{})
+	s.AddRouter(4, &unreliableEchoRouter{})
+
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(utils.Global().TcpPort))
+	var tcpConn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if tcpConn, err = net.Dial("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("dial err:", err)
+	}
+	defer tcpConn.Close()
+
+	//UDP通道建立之前通过TCP发送
+	dp := NewDataPack()
+	binaryMsg, _ := dp.Pack(NewMsgPackage(3, []byte("tcp")))
+	tcpConn.Write(binaryMsg)
+	tcpConn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	msg, err := dp.Unpack(tcpConn)
+	if err != nil || msg.GetMsgId() != 3 || string(msg.GetData()) != "tcp" {
+		t.Fatalf("unreliable msg before udp bind should use tcp, msg = %v, err = %v", msg, err)
+	}
+
+	binaryMsg, _ = dp.Pack(NewMsgPackage(2, nil))
+	tcpConn.Write(binaryMsg)
+	msg, err = dp.Unpack(tcpConn)
+	if err != nil || msg.GetMsgId() != UdpTokenMsgID || len(msg.GetData()) != 10 {
+		t.Fatalf("want udp token msg, msg = %v, err = %v", msg, err)
+	}
+	token := binary.LittleEndian.Uint64(msg.GetData())
+	port := binary.LittleEndian.Uint16(msg.GetData()[8:])
+	if int(port) != utils.Global().UdpPort {
+		t.Errorf("udp port = %d, want %d", port, utils.Global().UdpPort)
+	}
+
+	udpConn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: int(port)})
+	if err != nil {
+		t.Fatal("dial udp err:", err)
+	}
+	defer udpConn.Close()
+
+	//错误的token没有回复，hello绑定地址之后原样回复
+	udpConn.Write(packUdp(token+1, 0, UdpHelloMsgID, nil))
+	udpConn.Write(packUdp(token, 0, UdpHelloMsgID, nil))
+	if _, msgID, _ := readUdp(t, udpConn); msgID != UdpHelloMsgID {
+		t.Fatalf("msgID = %d, want udp hello", msgID)
+	}
+
+	udpConn.Write(packUdp(token, 5, 3, []byte("new")))
+	udpConn.Write(packUdp(token, 4, 3, []byte("old")))
+	udpConn.Write(packUdp(token, 6, 4, []byte("tcp only")))
+	udpConn.Write(packUdp(token, 7, 3, []byte("newer")))
+	lastSeq := uint32(1)
+	for _, want := range []string{"new", "newer"} {
+		seq, msgID, data := readUdp(t, udpConn)
+		if msgID != 3 || string(data) != want {
+			t.Errorf("msgID = %d, data = %s, want %s", msgID, data, want)
+		}
+		if seq <= lastSeq {
+			t.Errorf("server seq = %d, should be greater than %d", seq, lastSeq)
+		}
+		lastSeq = seq
+	}
+
+	//其他地址重放旧的数据报不能改变绑定的地址
+	other, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: int(port)})
+	if err != nil {
+		t.Fatal("dial udp err:", err)
+	}
+	defer other.Close()
+	other.Write(packUdp(token, 7, UdpHelloMsgID, nil))
+	other.Write(packUdp(token, 5, 3, []byte("replay")))
+	expectNoUdp(t, other, "replayed datagram")
+	udpConn.Write(packUdp(token, 8, 3, []byte("after replay")))
+	if _, msgID, data := readUdp(t, udpConn); msgID != 3 || string(data) != "after replay" {
+		t.Errorf("msgID = %d, data = %s, want after replay", msgID, data)
+	}
+
+	//链接断开之后UDP会话被移除
+	tcpConn.Close()
+	server := s.(*Server)
+	deadline := time.Now().Add(3 * time.Second)
+	for server.udp.len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if server.udp.len() != 0 {
+		t.Error("udp session should be removed after connection stop")
+	}
+}
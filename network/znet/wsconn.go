@@ -0,0 +1,83 @@
+package znet
+
+import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// 把WebSocket链接包装成net.Conn，让Connection可以像读写TCP数据流一样读写WebSocket
+// 每一个二进制WS消息承载一个或多个 | datalen | msgID | data | 数据包
+type wsConn struct {
+	conn *websocket.Conn
+	//当前正在读取的WS消息
+	reader io.Reader
+}
+
+func newWsConn(conn *websocket.Conn) *wsConn {
+	return &wsConn{conn: conn}
+}
+
+// 从WS消息中读取数据，当前消息读完之后自动读取下一个消息
+func (w *wsConn) Read(b []byte) (int, error) {
+	for {
+		if w.reader == nil {
+			msgType, reader, err := w.conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			if msgType != websocket.BinaryMessage {
+				return 0, errors.New("websocket only accept binary message")
+			}
+			w.reader = reader
+		}
+		n, err := w.reader.Read(b)
+		if err == io.EOF {
+			//当前消息已经读完
+			w.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// 每次写入作为一个二进制WS消息发送，Connection只会在Writer中写，所以不需要加锁
+func (w *wsConn) Write(b []byte) (int, error) {
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *wsConn) Close() error {
+	return w.conn.Close()
+}
+
+func (w *wsConn) LocalAddr() net.Addr {
+	return w.conn.LocalAddr()
+}
+
+func (w *wsConn) RemoteAddr() net.Addr {
+	return w.conn.RemoteAddr()
+}
+
+func (w *wsConn) SetDeadline(t time.Time) error {
+	if err := w.conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return w.conn.SetWriteDeadline(t)
+}
+
+func (w *wsConn) SetReadDeadline(t time.Time) error {
+	return w.conn.SetReadDeadline(t)
+}
+
+func (w *wsConn) SetWriteDeadline(t time.Time) error {
+	return w.conn.SetWriteDeadline(t)
+}
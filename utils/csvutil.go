@@ -233,8 +233,20 @@ func (self *CsvUtilMgr) trimNumber(s string) string {
 
 }
 
+// csv目录，当前目录下没有时向上查找，go test的工作目录是包所在的目录
+func csvDir() string {
+	dir := "csv"
+	for i := 0; i < 3; i++ {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir + "/"
+		}
+		dir = "../" + dir
+	}
+	return "csv/"
+}
+
 func (self *CsvUtilMgr) LoadCsv(fileName string, SlicePtr interface{}) {
-	csvFile := "csv/" + fileName + ".csv"
+	csvFile := csvDir() + fileName + ".csv"
 	csvData := self.readCsv(csvFile)
 	if len(csvData) <= 1 {
 		fmt.Println("len(csvData) <= 1, filename:", fileName)
@@ -251,7 +263,7 @@ func (self *CsvUtilMgr) LoadCsv(fileName string, SlicePtr interface{}) {
 }
 
 func (self *CsvUtilMgr) LoadEventsCsv(fileName string, SlicePtr interface{}) {
-	csvFile := "csv/ChapterMap/" + fileName + ".csv"
+	csvFile := csvDir() + "ChapterMap/" + fileName + ".csv"
 	csvData := self.readCsv(csvFile)
 	if len(csvData) <= 1 {
 		fmt.Println("len(csvData/ChapterMap) <= 1, fileName:", fileName)